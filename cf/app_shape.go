@@ -0,0 +1,253 @@
+package cf
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/cloudfoundry-community/go-cfclient"
+)
+
+// AppShape is what is created with each app besides its record,
+// so that the endpoints listing routes, service bindings and processes have something to authorize
+//
+// Everything is created with the V3 API, except that Instances and MemoryInMB scale the app's web process,
+// which only apps created with the V2 API have before they are staged.
+type AppShape struct {
+	// Routes is how many routes on the domain with DomainGUID are mapped to the app
+	Routes     int
+	DomainGUID string
+
+	// ServiceInstances is how many instances of the plan with ServicePlanGUID are created and bound to the app
+	ServiceInstances int
+	ServicePlanGUID  string
+
+	// EnvVars is how many environment variables are set on the app
+	EnvVars int
+
+	// Instances and MemoryInMB, if not 0, scale the app's web process
+	Instances  int
+	MemoryInMB int
+}
+
+// IsZero returns true if the shape creates nothing besides the app
+func (s AppShape) IsZero() bool {
+	return s == AppShape{}
+}
+
+// ShapeApp creates the routes, service instances, environment variables and process scale of the shape for the app
+//
+// Each is named after the app, so shaping an app again finds the existing ones rather than creating more.
+func ShapeApp(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, shape AppShape, appName string, appGUID string, spaceGUID string) error {
+	for i := 0; i < shape.Routes; i++ {
+		host := fmt.Sprintf("%s-%d", appName, i)
+		err := createAndMapRoute(ctx, logger.WithData(lager.Data{"route.host": host}), cfClient, host, shape.DomainGUID, spaceGUID, appGUID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < shape.ServiceInstances; i++ {
+		name := fmt.Sprintf("%s-si-%d", appName, i)
+		err := createAndBindServiceInstance(ctx, logger.WithData(lager.Data{"service-instance.name": name}), cfClient, name, shape.ServicePlanGUID, spaceGUID, appGUID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if shape.EnvVars > 0 {
+		vars := make(map[string]string)
+		for i := 0; i < shape.EnvVars; i++ {
+			vars[fmt.Sprintf("PERM_TEST_VAR_%d", i)] = fmt.Sprintf("%s-value-%d", appName, i)
+		}
+
+		err := updateApp(ctx, logger, cfClient, "set-app-env-vars", "PATCH", fmt.Sprintf("/v3/apps/%s/environment_variables", appGUID), map[string]interface{}{
+			"var": vars,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if shape.Instances > 0 || shape.MemoryInMB > 0 {
+		scale := make(map[string]int)
+		if shape.Instances > 0 {
+			scale["instances"] = shape.Instances
+		}
+		if shape.MemoryInMB > 0 {
+			scale["memory_in_mb"] = shape.MemoryInMB
+		}
+
+		err := updateApp(ctx, logger, cfClient, "scale-app", "POST", fmt.Sprintf("/v3/apps/%s/processes/web/actions/scale", appGUID), scale)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindDomain returns the GUID of the domain with the name
+func FindDomain(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, name string) (string, error) {
+	return findV3WithRetries(ctx, logger, cfClient, "domain", "/v3/domains?"+url.Values{"names": {name}}.Encode())
+}
+
+// FindServicePlan returns the GUID of the plan with the name of the service offering with the name
+func FindServicePlan(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, offering string, plan string) (string, error) {
+	query := url.Values{
+		"names":                  {plan},
+		"service_offering_names": {offering},
+	}
+	return findV3WithRetries(ctx, logger, cfClient, "service-plan", "/v3/service_plans?"+query.Encode())
+}
+
+func findV3WithRetries(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, resource string, path string) (string, error) {
+	var guid string
+	operation := func() error {
+		if err := wait(ctx, OperationList); err != nil {
+			return err
+		}
+
+		var err error
+		guid, err = findV3(cfClient, path)
+		return err
+	}
+
+	err := retryNotify(ctx, operation, func(err error, step time.Duration) {
+		logger.Error("failed-to-find-"+resource, err, lager.Data{
+			"backoff.step": step.String(),
+		})
+	})
+	if err != nil {
+		logger.Error("finally-failed-to-find-"+resource, err)
+		return "", err
+	}
+
+	return guid, nil
+}
+
+func createAndMapRoute(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, host string, domainGUID string, spaceGUID string, appGUID string) error {
+	logger.Debug("creating-route")
+	req := &CreateV3RouteRequestBody{
+		Host: host,
+		Relationships: RouteRelationships{
+			Domain: Relationship{Data: Data{GUID: domainGUID}},
+			Space:  Relationship{Data: Data{GUID: spaceGUID}},
+		},
+	}
+	routeGUID, err := createV3IfNotExists(ctx, logger, cfClient, OperationCreateRoute, "route", "/v3/routes", req, func() (string, error) {
+		query := url.Values{
+			"hosts":        {host},
+			"domain_guids": {domainGUID},
+		}
+		return findV3(cfClient, "/v3/routes?"+query.Encode())
+	})
+	if err != nil {
+		return err
+	}
+
+	// inserting a destination the route already has leaves it unchanged
+	return updateApp(ctx, logger, cfClient, "map-route", "POST", fmt.Sprintf("/v3/routes/%s/destinations", routeGUID), map[string]interface{}{
+		"destinations": []map[string]Relationship{
+			{"app": {Data: Data{GUID: appGUID}}},
+		},
+	})
+}
+
+func createAndBindServiceInstance(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, name string, planGUID string, spaceGUID string, appGUID string) error {
+	logger.Debug("creating-service-instance")
+	req := &CreateV3ServiceInstanceRequestBody{
+		Type: "managed",
+		Name: name,
+		Relationships: ServiceInstanceRelationships{
+			Space:       Relationship{Data: Data{GUID: spaceGUID}},
+			ServicePlan: Relationship{Data: Data{GUID: planGUID}},
+		},
+	}
+	instanceGUID, err := createV3IfNotExists(ctx, logger, cfClient, OperationCreateServiceInstance, "service-instance", "/v3/service_instances", req, func() (string, error) {
+		query := url.Values{
+			"names":       {name},
+			"space_guids": {spaceGUID},
+		}
+		return findV3(cfClient, "/v3/service_instances?"+query.Encode())
+	})
+	if err != nil {
+		return err
+	}
+
+	// the binding is retried until the instance has been provisioned by the broker
+	logger.Debug("binding-service-instance")
+	binding := &CreateV3ServiceCredentialBindingRequestBody{
+		Type: "app",
+		Relationships: ServiceCredentialBindingRelationships{
+			ServiceInstance: Relationship{Data: Data{GUID: instanceGUID}},
+			App:             Relationship{Data: Data{GUID: appGUID}},
+		},
+	}
+	_, err = createV3IfNotExists(ctx, logger, cfClient, OperationBindService, "service-binding", "/v3/service_credential_bindings", binding, func() (string, error) {
+		return "", nil
+	})
+	return err
+}
+
+// updateApp sends body to the V3 API at path using an exponential backoff strategy
+//
+// action names the update in log messages, e.g. failed-to-<action>
+func updateApp(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, action string, method string, path string, body interface{}) error {
+	logger.Debug(action)
+	operation := func() error {
+		if err := wait(ctx, OperationUpdateApp); err != nil {
+			return err
+		}
+
+		resp, err := requestV3(cfClient, method, path, body)
+		if err != nil {
+			return err
+		}
+
+		return resp.Body.Close()
+	}
+
+	err := retryNotify(ctx, operation, func(err error, step time.Duration) {
+		logger.Error("failed-to-"+action, err, lager.Data{
+			"backoff.step": step.String(),
+		})
+	})
+	if err != nil {
+		logger.Error("finally-failed-to-"+action, err)
+	}
+	return err
+}
+
+type CreateV3RouteRequestBody struct {
+	Host          string             `json:"host"`
+	Relationships RouteRelationships `json:"relationships"`
+}
+
+type RouteRelationships struct {
+	Domain Relationship `json:"domain"`
+	Space  Relationship `json:"space"`
+}
+
+type CreateV3ServiceInstanceRequestBody struct {
+	Type          string                       `json:"type"`
+	Name          string                       `json:"name"`
+	Relationships ServiceInstanceRelationships `json:"relationships"`
+}
+
+type ServiceInstanceRelationships struct {
+	Space       Relationship `json:"space"`
+	ServicePlan Relationship `json:"service_plan"`
+}
+
+type CreateV3ServiceCredentialBindingRequestBody struct {
+	Type          string                                `json:"type"`
+	Relationships ServiceCredentialBindingRelationships `json:"relationships"`
+}
+
+type ServiceCredentialBindingRelationships struct {
+	ServiceInstance Relationship `json:"service_instance"`
+	App             Relationship `json:"app"`
+}
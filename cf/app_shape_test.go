@@ -0,0 +1,130 @@
+package cf_test
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/pivotal-cf/perm-test/cf"
+)
+
+var _ = Describe("AppShape", func() {
+	var (
+		server *ghttp.Server
+
+		seeder *CloudControllerSeeder
+		logger *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/info"),
+			ghttp.RespondWith(200, "{}", nil),
+		))
+
+		cfClient, err := cfclient.NewClient(&cfclient.Config{
+			ApiAddress: "http://" + server.Addr(),
+			Token:      "foobar",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		seeder = NewCloudControllerSeeder(cfClient)
+		logger = lagertest.NewTestLogger("app-shape")
+
+		policy := DefaultRetryPolicy()
+		policy.InitialInterval = time.Millisecond
+		policy.MaxElapsedTime = time.Second
+		SetRetryPolicy(policy)
+	})
+
+	AfterEach(func() {
+		SetRetryPolicy(DefaultRetryPolicy())
+		server.Close()
+	})
+
+	It("creates the routes, service instances, environment variables and scale of every app", func() {
+		seeder.AppShape = AppShape{
+			Routes:           1,
+			DomainGUID:       "some-domain-guid",
+			ServiceInstances: 1,
+			ServicePlanGUID:  "some-plan-guid",
+			EnvVars:          2,
+			Instances:        3,
+			MemoryInMB:       256,
+		}
+
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v2/apps"),
+				ghttp.RespondWith(201, `{"metadata": {"guid": "some-app-guid"}, "entity": {"name": "some-app"}}`, nil),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v3/routes"),
+				ghttp.VerifyJSON(`{"host": "some-app-0", "relationships": {"domain": {"data": {"guid": "some-domain-guid"}}, "space": {"data": {"guid": "some-space-guid"}}}}`),
+				ghttp.RespondWith(201, `{"guid": "some-route-guid"}`, nil),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v3/routes/some-route-guid/destinations"),
+				ghttp.VerifyJSON(`{"destinations": [{"app": {"data": {"guid": "some-app-guid"}}}]}`),
+				ghttp.RespondWith(200, `{}`, nil),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v3/service_instances"),
+				ghttp.VerifyJSON(`{"type": "managed", "name": "some-app-si-0", "relationships": {"space": {"data": {"guid": "some-space-guid"}}, "service_plan": {"data": {"guid": "some-plan-guid"}}}}`),
+				ghttp.RespondWith(202, ``, nil),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v3/service_instances", "names=some-app-si-0&space_guids=some-space-guid"),
+				ghttp.RespondWith(200, `{"resources": [{"guid": "some-instance-guid"}]}`, nil),
+			),
+			ghttp.RespondWith(422, `{"errors": [{"code": 10008, "title": "CF-UnprocessableEntity", "detail": "There is an operation in progress for the service instance."}]}`, nil),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v3/service_credential_bindings"),
+				ghttp.VerifyJSON(`{"type": "app", "relationships": {"service_instance": {"data": {"guid": "some-instance-guid"}}, "app": {"data": {"guid": "some-app-guid"}}}}`),
+				ghttp.RespondWith(202, ``, nil),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PATCH", "/v3/apps/some-app-guid/environment_variables"),
+				ghttp.VerifyJSON(`{"var": {"PERM_TEST_VAR_0": "some-app-value-0", "PERM_TEST_VAR_1": "some-app-value-1"}}`),
+				ghttp.RespondWith(200, `{}`, nil),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v3/apps/some-app-guid/processes/web/actions/scale"),
+				ghttp.VerifyJSON(`{"instances": 3, "memory_in_mb": 256}`),
+				ghttp.RespondWith(202, `{}`, nil),
+			),
+		)
+
+		guid, err := seeder.CreateApp(context.Background(), logger, "some-app", "some-space-guid")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(guid).To(Equal("some-app-guid"))
+		Expect(server.ReceivedRequests()).To(HaveLen(10))
+	})
+
+	It("shapes an app which already exists", func() {
+		seeder.AppShape = AppShape{EnvVars: 1}
+
+		server.AppendHandlers(
+			ghttp.RespondWith(400, `{"code": 100002, "error_code": "CF-AppNameTaken", "description": "The app name is taken: some-app"}`, nil),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/apps", "q=name%3Asome-app&q=space_guid%3Asome-space-guid"),
+				ghttp.RespondWith(200, `{"resources": [{"metadata": {"guid": "some-app-guid"}, "entity": {"name": "some-app"}}]}`, nil),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PATCH", "/v3/apps/some-app-guid/environment_variables"),
+				ghttp.RespondWith(200, `{}`, nil),
+			),
+		)
+
+		guid, err := seeder.CreateApp(context.Background(), logger, "some-app", "some-space-guid")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(guid).To(Equal("some-app-guid"))
+	})
+})
@@ -0,0 +1,72 @@
+package cf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/cloudfoundry-community/go-cfclient"
+	"github.com/pivotal-cf/perm-test/cf/internal"
+)
+
+// AssociateOrgManager makes the user a manager of the org
+func AssociateOrgManager(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, userGUID string, orgGUID string) error {
+	return assignRole(ctx, logger, cfClient, OperationAssociateOrgManager, "associate-org-manager", fmt.Sprintf("/v2/organizations/%s/managers/%s", orgGUID, userGUID))
+}
+
+// AssociateOrgBillingManager makes the user a billing manager of the org
+func AssociateOrgBillingManager(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, userGUID string, orgGUID string) error {
+	return assignRole(ctx, logger, cfClient, OperationAssociateOrgBillingManager, "associate-org-billing-manager", fmt.Sprintf("/v2/organizations/%s/billing_managers/%s", orgGUID, userGUID))
+}
+
+// AssociateOrgAuditor makes the user an auditor of the org
+func AssociateOrgAuditor(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, userGUID string, orgGUID string) error {
+	return assignRole(ctx, logger, cfClient, OperationAssociateOrgAuditor, "associate-org-auditor", fmt.Sprintf("/v2/organizations/%s/auditors/%s", orgGUID, userGUID))
+}
+
+// MakeUserSpaceManager makes the user a manager of the space
+func MakeUserSpaceManager(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, userGUID string, spaceGUID string) error {
+	return assignRole(ctx, logger, cfClient, OperationMakeUserSpaceManager, "make-user-space-manager", fmt.Sprintf("/v2/spaces/%s/managers/%s", spaceGUID, userGUID))
+}
+
+// MakeUserSpaceAuditor makes the user an auditor of the space
+func MakeUserSpaceAuditor(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, userGUID string, spaceGUID string) error {
+	return assignRole(ctx, logger, cfClient, OperationMakeUserSpaceAuditor, "make-user-space-auditor", fmt.Sprintf("/v2/spaces/%s/auditors/%s", spaceGUID, userGUID))
+}
+
+// assignRole PUTs the V2 role association at path using an exponential backoff strategy
+//
+// action names the association in log messages, e.g. failed-to-<action>
+func assignRole(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, op string, action string, path string) error {
+	logger.Debug(action)
+	r := cfClient.NewRequest("PUT", path)
+	operation := func() error {
+		if err := wait(ctx, op); err != nil {
+			return err
+		}
+
+		resp, err := cfClient.DoRequest(r)
+		if err != nil {
+			return internal.ResponseError(resp, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusCreated {
+			return &internal.StatusError{StatusCode: resp.StatusCode}
+		}
+
+		return nil
+	}
+	err := retryNotify(ctx, operation, func(err error, step time.Duration) {
+		logger.Error("failed-to-"+action, err, lager.Data{
+			"backoff.step": step.String(),
+		})
+	})
+
+	if err != nil {
+		logger.Error("finally-failed-to-"+action, err)
+	}
+	return err
+}
@@ -1,23 +1,24 @@
 package cf
 
 import (
+	"context"
 	"encoding/json"
 	"time"
 
 	"code.cloudfoundry.org/lager"
-	"github.com/cenkalti/backoff"
 	"github.com/cloudfoundry-community/go-cfclient"
+	"github.com/pivotal-cf/perm-test/cf/internal"
 )
 
-func OrgCount(logger lager.Logger, cfClient *cfclient.Client) (int, error) {
-	return getCount(logger, cfClient, "/v3/organizations")
+func OrgCount(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client) (int, error) {
+	return getCount(ctx, logger, cfClient, "/v3/organizations")
 }
 
-func SpaceCount(logger lager.Logger, cfClient *cfclient.Client) (int, error) {
-	return getCount(logger, cfClient, "/v3/spaces")
+func SpaceCount(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client) (int, error) {
+	return getCount(ctx, logger, cfClient, "/v3/spaces")
 }
 
-func UserCount(logger lager.Logger, cfClient *cfclient.Client) (int, error) {
+func UserCount(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client) (int, error) {
 	req := cfClient.NewRequest("GET", "/v2/users")
 
 	var (
@@ -25,10 +26,14 @@ func UserCount(logger lager.Logger, cfClient *cfclient.Client) (int, error) {
 		err   error
 	)
 	operation := func() error {
+		if err := wait(ctx, OperationCount); err != nil {
+			return err
+		}
+
 		resp, err := cfClient.DoRequest(req)
 		if err != nil {
 			logger.Error("failed-to-list-users", err)
-			return err
+			return internal.ResponseError(resp, err)
 		}
 
 		var r v2CountResponse
@@ -43,7 +48,7 @@ func UserCount(logger lager.Logger, cfClient *cfclient.Client) (int, error) {
 		return nil
 	}
 
-	err = backoff.RetryNotify(operation, backoff.NewExponentialBackOff(), func(err error, step time.Duration) {
+	err = retryNotify(ctx, operation, func(err error, step time.Duration) {
 		logger.Error("failed-to-get-user-count", err, lager.Data{
 			"backoff.step": step.String(),
 		})
@@ -64,7 +69,7 @@ type v3CountResponse struct {
 	Pagination v2CountResponse `json:"pagination"`
 }
 
-func getCount(logger lager.Logger, cfClient *cfclient.Client, path string) (int, error) {
+func getCount(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, path string) (int, error) {
 	req := cfClient.NewRequest("GET", path)
 
 	var (
@@ -72,10 +77,14 @@ func getCount(logger lager.Logger, cfClient *cfclient.Client, path string) (int,
 		err   error
 	)
 	operation := func() error {
+		if err := wait(ctx, OperationCount); err != nil {
+			return err
+		}
+
 		resp, err := cfClient.DoRequest(req)
 		if err != nil {
 			logger.Error("failed-to-list", err)
-			return err
+			return internal.ResponseError(resp, err)
 		}
 
 		var r v3CountResponse
@@ -90,7 +99,7 @@ func getCount(logger lager.Logger, cfClient *cfclient.Client, path string) (int,
 		return nil
 	}
 
-	err = backoff.RetryNotify(operation, backoff.NewExponentialBackOff(), func(err error, step time.Duration) {
+	err = retryNotify(ctx, operation, func(err error, step time.Duration) {
 		logger.Error("failed-to-get-count", err, lager.Data{
 			"backoff.step": step.String(),
 		})
@@ -1,6 +1,8 @@
 package cf_test
 
 import (
+	"context"
+
 	"code.cloudfoundry.org/lager/lagertest"
 	"github.com/cloudfoundry-community/go-cfclient"
 	. "github.com/onsi/ginkgo"
@@ -48,7 +50,7 @@ var _ = Describe("Count", func() {
 				ghttp.RespondWith(200, `{"pagination": {"total_results": 42}}`, nil),
 			))
 
-			count, err := OrgCount(logger, cfClient)
+			count, err := OrgCount(context.Background(), logger, cfClient)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(count).To(Equal(42))
@@ -62,7 +64,7 @@ var _ = Describe("Count", func() {
 				ghttp.RespondWith(200, `{"pagination": {"total_results": 42}}`, nil),
 			))
 
-			count, err := SpaceCount(logger, cfClient)
+			count, err := SpaceCount(context.Background(), logger, cfClient)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(count).To(Equal(42))
@@ -76,7 +78,7 @@ var _ = Describe("Count", func() {
 				ghttp.RespondWith(200, `{"total_results": 42}`, nil),
 			))
 
-			count, err := UserCount(logger, cfClient)
+			count, err := UserCount(context.Background(), logger, cfClient)
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(count).To(Equal(42))
@@ -2,27 +2,33 @@ package cf
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"code.cloudfoundry.org/lager"
-	"github.com/cenkalti/backoff"
 	"github.com/cloudfoundry-community/go-cfclient"
 	"github.com/pivotal-cf/perm-test/cf/internal"
 )
 
-// CreateAppIfNotExists creates an app in CloudFoundry using the V2 API
+// CreateAppIfNotExists creates an app in CloudFoundry using the V2 API, returning its GUID
 // It uses an exponential backoff strategy, returning early if it successfully creates
-// an app or the app already exists
-func CreateAppIfNotExists(logger lager.Logger, cfClient *cfclient.Client, name string, spaceGUID string) error {
+// an app or the app already exists, in which case the existing app's GUID is returned
+func CreateAppIfNotExists(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, name string, spaceGUID string) (string, error) {
 	req := &CreateV2AppRequestBody{
 		Name:      name,
 		SpaceGUID: spaceGUID,
 	}
 
+	var guid string
 	operation := func() error {
+		if err := wait(ctx, OperationCreateApp); err != nil {
+			return err
+		}
+
 		b := bytes.NewBuffer(nil)
 		err := json.NewEncoder(b).Encode(req)
 		if err != nil {
@@ -39,41 +45,48 @@ func CreateAppIfNotExists(logger lager.Logger, cfClient *cfclient.Client, name s
 		// due to timeout. When it tries the operation a second time, it fails
 		// with "name must be unique in space" error, because it already exists.
 		//
-		// To get around this, we return nil if we detect this case, which causes
-		// the backoff function to stop retrying.
+		// To get around this, we look up the existing app if we detect this case,
+		// which causes the backoff function to stop retrying.
 		//
-		switch e := err.(type) {
-		case nil:
-			if resp.StatusCode != http.StatusCreated {
-				err = fmt.Errorf("Incorrect status code (%d)", resp.StatusCode)
-
-			}
-			return err
-
-		case cfclient.CloudFoundryErrors:
-			if len(e.Errors) == 0 {
+		if internal.HasErrorCode(err, internal.AppNameTaken) {
+			logger.Debug("app-already-exists")
+			if err := wait(ctx, OperationCreateApp); err != nil {
 				return err
 			}
 
-			for _, cfError := range e.Errors {
-				if cfError.ErrorCode == internal.AppNameTaken {
-					return nil
-				}
+			var apps []cfclient.App
+			apps, err = cfClient.ListAppsByQuery(url.Values{
+				"q": {"name:" + name, "space_guid:" + spaceGUID},
+			})
+			if err != nil {
+				return err
 			}
-		case cfclient.CloudFoundryError:
-			if e.ErrorCode == internal.AppNameTaken {
-				return nil
+			if len(apps) != 1 {
+				return fmt.Errorf("expected one app named %s, found %d", name, len(apps))
 			}
+			guid = apps[0].Guid
+			return nil
+		}
+		if err != nil {
+			return internal.ResponseError(resp, err)
+		}
+		defer resp.Body.Close()
 
-			return err
-		default:
-			return err
+		if resp.StatusCode != http.StatusCreated {
+			return &internal.StatusError{StatusCode: resp.StatusCode}
+		}
+
+		var app cfclient.AppResource
+		err = json.NewDecoder(resp.Body).Decode(&app)
+		if err != nil {
+			return &internal.StatusError{StatusCode: resp.StatusCode, Err: err}
 		}
+		guid = app.Meta.Guid
 
 		return nil
 	}
 
-	err := backoff.RetryNotify(operation, backoff.NewExponentialBackOff(), func(err error, step time.Duration) {
+	err := retryNotify(ctx, operation, func(err error, step time.Duration) {
 		logger.Error("failed-to-create-app", err, lager.Data{
 			"backoff.step": step.String(),
 		})
@@ -81,9 +94,30 @@ func CreateAppIfNotExists(logger lager.Logger, cfClient *cfclient.Client, name s
 
 	if err != nil {
 		logger.Error("finally-failed-to-create-app", err)
+		return "", err
 	}
 
-	return err
+	return guid, nil
+}
+
+// CreateV3AppIfNotExists creates an app in CloudFoundry using the V3 API, returning its GUID
+// It uses an exponential backoff strategy, returning early if it successfully creates
+// an app or the app already exists, in which case the existing app's GUID is returned
+func CreateV3AppIfNotExists(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, name string, spaceGUID string) (string, error) {
+	req := &CreateV3AppRequestBody{
+		Name: name,
+		Relationships: SpaceRelationship{
+			Space: Space{Data: Data{GUID: spaceGUID}},
+		},
+	}
+
+	return createV3IfNotExists(ctx, logger, cfClient, OperationCreateApp, "app", "/v3/apps", req, func() (string, error) {
+		query := url.Values{
+			"names":       {name},
+			"space_guids": {spaceGUID},
+		}
+		return findV3(cfClient, "/v3/apps?"+query.Encode())
+	})
 }
 
 type SpaceGUID string
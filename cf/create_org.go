@@ -1,18 +1,19 @@
 package cf
 
 import (
+	"context"
+	"net/url"
 	"time"
 
 	"code.cloudfoundry.org/lager"
-	"github.com/cenkalti/backoff"
 	"github.com/cloudfoundry-community/go-cfclient"
 	"github.com/pivotal-cf/perm-test/cf/internal"
 )
 
 // CreateOrgIfNotExists creates an org in CloudFoundry using the V2 API
 // It uses an exponential backoff strategy, returning early if it successfully creates
-// an org or the org already exists
-func CreateOrgIfNotExists(logger lager.Logger, cfClient *cfclient.Client, orgName string) (*cfclient.Org, error) {
+// an org or the org already exists, in which case the existing org is returned
+func CreateOrgIfNotExists(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, orgName string) (*cfclient.Org, error) {
 	logger.Debug("creating-org", lager.Data{
 		"name": orgName,
 	})
@@ -26,34 +27,24 @@ func CreateOrgIfNotExists(logger lager.Logger, cfClient *cfclient.Client, orgNam
 		err error
 	)
 	operation := func() error {
-		org, err = cfClient.CreateOrg(orgRequest)
-		switch e := err.(type) {
-		case nil:
-			return nil
+		if err := wait(ctx, OperationCreateOrg); err != nil {
+			return err
+		}
 
-		case cfclient.CloudFoundryErrors:
-			if len(e.Errors) == 0 {
+		org, err = cfClient.CreateOrg(orgRequest)
+		if internal.HasErrorCode(err, internal.OrganizationNameTaken) {
+			logger.Debug("org-already-exists")
+			if err := wait(ctx, OperationCreateOrg); err != nil {
 				return err
 			}
 
-			cfError := e.Errors[0]
-			if cfError.ErrorCode == internal.OrganizationNameTaken {
-				return nil
-			}
-		case cfclient.CloudFoundryError:
-			if e.ErrorCode == internal.OrganizationNameTaken {
-				return nil
-			}
-
-			return err
-		default:
-			return err
+			org, err = cfClient.GetOrgByName(orgName)
 		}
 
 		return err
 	}
 
-	err = backoff.RetryNotify(operation, backoff.NewExponentialBackOff(), func(err error, step time.Duration) {
+	err = retryNotify(ctx, operation, func(err error, step time.Duration) {
 		logger.Error("failed-to-create-org", err, lager.Data{
 			"backoff.step": step.String(),
 		})
@@ -64,3 +55,23 @@ func CreateOrgIfNotExists(logger lager.Logger, cfClient *cfclient.Client, orgNam
 	}
 	return &org, nil
 }
+
+// CreateV3OrgIfNotExists creates an org in CloudFoundry using the V3 API, returning its GUID
+// It uses an exponential backoff strategy, returning early if it successfully creates
+// an org or the org already exists, in which case the existing org's GUID is returned
+func CreateV3OrgIfNotExists(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, orgName string) (string, error) {
+	logger.Debug("creating-org", lager.Data{
+		"name": orgName,
+	})
+
+	req := &CreateV3OrgRequestBody{
+		Name: orgName,
+	}
+	return createV3IfNotExists(ctx, logger, cfClient, OperationCreateOrg, "org", "/v3/organizations", req, func() (string, error) {
+		return findV3(cfClient, "/v3/organizations?names="+url.QueryEscape(orgName))
+	})
+}
+
+type CreateV3OrgRequestBody struct {
+	Name string `json:"name"`
+}
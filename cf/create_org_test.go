@@ -0,0 +1,91 @@
+package cf_test
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/pivotal-cf/perm-test/cf"
+)
+
+var _ = Describe("CreateOrgIfNotExists", func() {
+	var (
+		server *ghttp.Server
+
+		cfClient *cfclient.Client
+		logger   *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/info"),
+			ghttp.RespondWith(200, "{}", nil),
+		))
+
+		var err error
+		cfClient, err = cfclient.NewClient(&cfclient.Config{
+			ApiAddress: "http://" + server.Addr(),
+			Token:      "foobar",
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("create-org")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("returns the created org", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("POST", "/v2/organizations"),
+			ghttp.VerifyJSON(`{"name": "some-org"}`),
+			ghttp.RespondWith(201, `{"metadata": {"guid": "some-org-guid"}, "entity": {"name": "some-org"}}`, nil),
+		))
+
+		org, err := CreateOrgIfNotExists(context.Background(), logger, cfClient, "some-org")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(org.Guid).To(Equal("some-org-guid"))
+	})
+
+	It("looks up the existing org when the name is taken", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v2/organizations"),
+				ghttp.RespondWith(400, `{"code": 30002, "error_code": "CF-OrganizationNameTaken", "description": "The organization name is taken: some-org"}`, nil),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/organizations", "q=name%3Asome-org"),
+				ghttp.RespondWith(200, `{"resources": [{"metadata": {"guid": "existing-org-guid"}, "entity": {"name": "some-org"}}]}`, nil),
+			),
+		)
+
+		org, err := CreateOrgIfNotExists(context.Background(), logger, cfClient, "some-org")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(org.Guid).To(Equal("existing-org-guid"))
+	})
+
+	It("stops retrying once the context is done", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("POST", "/v2/organizations"),
+			ghttp.RespondWith(500, `{}`, nil),
+		))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := CreateOrgIfNotExists(ctx, logger, cfClient, "some-org")
+		Expect(err).To(HaveOccurred())
+
+		Expect(server.ReceivedRequests()).To(HaveLen(2))
+	})
+})
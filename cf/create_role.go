@@ -0,0 +1,118 @@
+package cf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/cloudfoundry-community/go-cfclient"
+	"github.com/pivotal-cf/perm-test/cf/internal"
+)
+
+// Types of the roles created by CreateRole
+const (
+	RoleTypeOrganizationUser           = "organization_user"
+	RoleTypeOrganizationManager        = "organization_manager"
+	RoleTypeOrganizationBillingManager = "organization_billing_manager"
+	RoleTypeOrganizationAuditor        = "organization_auditor"
+	RoleTypeSpaceDeveloper             = "space_developer"
+	RoleTypeSpaceManager               = "space_manager"
+	RoleTypeSpaceAuditor               = "space_auditor"
+)
+
+// roleOperations maps each role type to the operation its requests are rate limited by,
+// the same as the V2 association it replaces
+var roleOperations = map[string]string{
+	RoleTypeOrganizationUser:           OperationAssociateUserWithOrg,
+	RoleTypeOrganizationManager:        OperationAssociateOrgManager,
+	RoleTypeOrganizationBillingManager: OperationAssociateOrgBillingManager,
+	RoleTypeOrganizationAuditor:        OperationAssociateOrgAuditor,
+	RoleTypeSpaceDeveloper:             OperationMakeUserSpaceDeveloper,
+	RoleTypeSpaceManager:               OperationMakeUserSpaceManager,
+	RoleTypeSpaceAuditor:               OperationMakeUserSpaceAuditor,
+}
+
+// CreateRole gives the user a role in an org or space using the V3 API,
+// one POST /v3/roles per role rather than a V2 association per role
+// It uses an exponential backoff strategy, returning early if it successfully creates
+// the role or the user already has it
+func CreateRole(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, roleType string, userGUID string, targetGUID string) error {
+	op, ok := roleOperations[roleType]
+	if !ok {
+		return fmt.Errorf("unknown role type %s", roleType)
+	}
+
+	req := &CreateV3RoleRequestBody{
+		Type: roleType,
+		Relationships: RoleRelationships{
+			User: &Relationship{Data: Data{GUID: userGUID}},
+		},
+	}
+	if strings.HasPrefix(roleType, "space_") {
+		req.Relationships.Space = &Relationship{Data: Data{GUID: targetGUID}}
+	} else {
+		req.Relationships.Organization = &Relationship{Data: Data{GUID: targetGUID}}
+	}
+
+	logger.Debug("creating-role", lager.Data{
+		"type": roleType,
+	})
+	operation := func() error {
+		if err := wait(ctx, op); err != nil {
+			return err
+		}
+
+		b := bytes.NewBuffer(nil)
+		err := json.NewEncoder(b).Encode(req)
+		if err != nil {
+			return err
+		}
+
+		resp, err := cfClient.DoRequest(cfClient.NewRequestWithBody("POST", "/v3/roles", b))
+		if internal.IsAlreadyExists(err) {
+			logger.Debug("role-already-exists")
+			return nil
+		}
+		if err != nil {
+			return internal.ResponseError(resp, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusCreated {
+			return &internal.StatusError{StatusCode: resp.StatusCode}
+		}
+
+		return nil
+	}
+
+	err := retryNotify(ctx, operation, func(err error, step time.Duration) {
+		logger.Error("failed-to-create-role", err, lager.Data{
+			"backoff.step": step.String(),
+		})
+	})
+
+	if err != nil {
+		logger.Error("finally-failed-to-create-role", err)
+	}
+	return err
+}
+
+type CreateV3RoleRequestBody struct {
+	Type          string            `json:"type"`
+	Relationships RoleRelationships `json:"relationships"`
+}
+
+type RoleRelationships struct {
+	User         *Relationship `json:"user"`
+	Organization *Relationship `json:"organization,omitempty"`
+	Space        *Relationship `json:"space,omitempty"`
+}
+
+type Relationship struct {
+	Data Data `json:"data"`
+}
@@ -0,0 +1,103 @@
+package cf_test
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/pivotal-cf/perm-test/cf"
+)
+
+var _ = Describe("CreateRole", func() {
+	var (
+		server *ghttp.Server
+
+		cfClient *cfclient.Client
+		logger   *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/info"),
+			ghttp.RespondWith(200, "{}", nil),
+		))
+
+		var err error
+		cfClient, err = cfclient.NewClient(&cfclient.Config{
+			ApiAddress: "http://" + server.Addr(),
+			Token:      "foobar",
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("create-role")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("creates an org role", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("POST", "/v3/roles"),
+			ghttp.VerifyJSON(`{
+				"type": "organization_user",
+				"relationships": {
+					"user": {"data": {"guid": "some-user-guid"}},
+					"organization": {"data": {"guid": "some-org-guid"}}
+				}
+			}`),
+			ghttp.RespondWith(201, `{"guid": "some-role-guid"}`, nil),
+		))
+
+		err := CreateRole(context.Background(), logger, cfClient, RoleTypeOrganizationUser, "some-user-guid", "some-org-guid")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("creates a space role", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("POST", "/v3/roles"),
+			ghttp.VerifyJSON(`{
+				"type": "space_auditor",
+				"relationships": {
+					"user": {"data": {"guid": "some-user-guid"}},
+					"space": {"data": {"guid": "some-space-guid"}}
+				}
+			}`),
+			ghttp.RespondWith(201, `{"guid": "some-role-guid"}`, nil),
+		))
+
+		err := CreateRole(context.Background(), logger, cfClient, RoleTypeSpaceAuditor, "some-user-guid", "some-space-guid")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("succeeds without retrying if the user already has the role", func() {
+		server.AppendHandlers(ghttp.RespondWith(422, `{"errors": [{
+			"code": 10008,
+			"title": "CF-UnprocessableEntity",
+			"detail": "User 'some-user' already has 'organization_user' role in organization 'some-org'."
+		}]}`, nil))
+
+		err := CreateRole(context.Background(), logger, cfClient, RoleTypeOrganizationUser, "some-user-guid", "some-org-guid")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(server.ReceivedRequests()).To(HaveLen(2))
+	})
+
+	It("fails fast on other unprocessable roles", func() {
+		server.AppendHandlers(ghttp.RespondWith(422, `{"errors": [{
+			"code": 10008,
+			"title": "CF-UnprocessableEntity",
+			"detail": "Users cannot be assigned roles in a space if they do not have a role in that space's organization."
+		}]}`, nil))
+
+		err := CreateRole(context.Background(), logger, cfClient, RoleTypeSpaceDeveloper, "some-user-guid", "some-space-guid")
+		Expect(err).To(MatchError(ContainSubstring("do not have a role in that space's organization")))
+		Expect(server.ReceivedRequests()).To(HaveLen(2))
+	})
+})
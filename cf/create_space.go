@@ -1,18 +1,19 @@
 package cf
 
 import (
+	"context"
+	"net/url"
 	"time"
 
 	"code.cloudfoundry.org/lager"
-	"github.com/cenkalti/backoff"
 	"github.com/cloudfoundry-community/go-cfclient"
 	"github.com/pivotal-cf/perm-test/cf/internal"
 )
 
 // CreateSpaceIfNotExists creates a space in CloudFoundry using the V2 API
 // It uses an exponential backoff strategy, returning early if it successfully creates
-// a space or the space already exists
-func CreateSpaceIfNotExists(logger lager.Logger, cfClient *cfclient.Client, spaceName string, orgGUID string) (*cfclient.Space, error) {
+// a space or the space already exists, in which case the existing space is returned
+func CreateSpaceIfNotExists(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, spaceName string, orgGUID string) (*cfclient.Space, error) {
 	logger.Debug("creating-space")
 	spaceRequest := cfclient.SpaceRequest{
 		Name:             spaceName,
@@ -24,35 +25,24 @@ func CreateSpaceIfNotExists(logger lager.Logger, cfClient *cfclient.Client, spac
 	)
 
 	operation := func() error {
-		space, err = cfClient.CreateSpace(spaceRequest)
-		switch e := err.(type) {
-		case nil:
-			return nil
+		if err := wait(ctx, OperationCreateSpace); err != nil {
+			return err
+		}
 
-		case cfclient.CloudFoundryErrors:
-			if len(e.Errors) == 0 {
+		space, err = cfClient.CreateSpace(spaceRequest)
+		if internal.HasErrorCode(err, internal.SpaceNameTaken) {
+			logger.Debug("space-already-exists")
+			if err := wait(ctx, OperationCreateSpace); err != nil {
 				return err
 			}
 
-			for _, cfError := range e.Errors {
-				if cfError.ErrorCode == internal.SpaceNameTaken {
-					return nil
-				}
-			}
-		case cfclient.CloudFoundryError:
-			if e.ErrorCode == internal.SpaceNameTaken {
-				return nil
-			}
-
-			return err
-		default:
-			return err
+			space, err = cfClient.GetSpaceByName(spaceName, orgGUID)
 		}
 
 		return err
 	}
 
-	err = backoff.RetryNotify(operation, backoff.NewExponentialBackOff(), func(err error, step time.Duration) {
+	err = retryNotify(ctx, operation, func(err error, step time.Duration) {
 		logger.Error("failed-to-create-space", err, lager.Data{
 			"backoff.step": step.String(),
 		})
@@ -65,3 +55,33 @@ func CreateSpaceIfNotExists(logger lager.Logger, cfClient *cfclient.Client, spac
 
 	return &space, nil
 }
+
+// CreateV3SpaceIfNotExists creates a space in CloudFoundry using the V3 API, returning its GUID
+// It uses an exponential backoff strategy, returning early if it successfully creates
+// a space or the space already exists, in which case the existing space's GUID is returned
+func CreateV3SpaceIfNotExists(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, spaceName string, orgGUID string) (string, error) {
+	logger.Debug("creating-space")
+
+	req := &CreateV3SpaceRequestBody{
+		Name: spaceName,
+		Relationships: OrganizationRelationship{
+			Organization: Relationship{Data: Data{GUID: orgGUID}},
+		},
+	}
+	return createV3IfNotExists(ctx, logger, cfClient, OperationCreateSpace, "space", "/v3/spaces", req, func() (string, error) {
+		query := url.Values{
+			"names":              {spaceName},
+			"organization_guids": {orgGUID},
+		}
+		return findV3(cfClient, "/v3/spaces?"+query.Encode())
+	})
+}
+
+type CreateV3SpaceRequestBody struct {
+	Name          string                   `json:"name"`
+	Relationships OrganizationRelationship `json:"relationships"`
+}
+
+type OrganizationRelationship struct {
+	Organization Relationship `json:"organization"`
+}
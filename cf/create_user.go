@@ -1,22 +1,75 @@
 package cf
 
 import (
+	"context"
+	"time"
+
 	"code.cloudfoundry.org/lager"
 	"github.com/cloudfoundry-community/go-cfclient"
+	"github.com/pivotal-cf/perm-test/cf/internal"
 )
 
-func CreateUser(logger lager.Logger, cfClient *cfclient.Client, userGUID string) (*cfclient.User, error) {
+// CreateUser creates a user in CloudFoundry using the V2 API
+// It uses an exponential backoff strategy, returning early if it successfully creates
+// the user or a user with the GUID already exists, in which case the existing user is returned
+func CreateUser(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, userGUID string) (*cfclient.User, error) {
 	userRequest := cfclient.UserRequest{
 		Guid: userGUID,
 	}
 	logger.Debug("creating-user", lager.Data{
 		"guid": userGUID,
 	})
-	user, err := cfClient.CreateUser(userRequest)
+
+	var user cfclient.User
+	operation := func() error {
+		if err := wait(ctx, OperationCreateUser); err != nil {
+			return err
+		}
+
+		var err error
+		user, err = cfClient.CreateUser(userRequest)
+		if internal.HasErrorCode(err, internal.UaaIDTaken) {
+			logger.Debug("user-already-exists")
+			if err := wait(ctx, OperationCreateUser); err != nil {
+				return err
+			}
+
+			user, err = cfClient.GetUserByGUID(userGUID)
+		}
+
+		return err
+	}
+
+	err := retryNotify(ctx, operation, func(err error, step time.Duration) {
+		logger.Error("failed-to-create-cf-user", err, lager.Data{
+			"backoff.step": step.String(),
+		})
+	})
 	if err != nil {
-		logger.Error("failed-to-create-cf-user", err)
+		logger.Error("finally-failed-to-create-cf-user", err)
 		return nil, err
 	}
 
 	return &user, nil
 }
+
+// CreateV3User creates a user in CloudFoundry using the V3 API
+// It uses an exponential backoff strategy, returning early if it successfully creates
+// the user or a user with the GUID already exists
+func CreateV3User(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, userGUID string) error {
+	logger.Debug("creating-user", lager.Data{
+		"guid": userGUID,
+	})
+
+	req := &CreateV3UserRequestBody{
+		GUID: userGUID,
+	}
+	_, err := createV3IfNotExists(ctx, logger, cfClient, OperationCreateUser, "cf-user", "/v3/users", req, func() (string, error) {
+		return userGUID, nil
+	})
+	return err
+}
+
+type CreateV3UserRequestBody struct {
+	GUID string `json:"guid"`
+}
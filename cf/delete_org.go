@@ -0,0 +1,158 @@
+package cf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/cloudfoundry-community/go-cfclient"
+	"github.com/pivotal-cf/perm-test/cf/internal"
+)
+
+const (
+	JobStatusFinished = "finished"
+	JobStatusFailed   = "failed"
+)
+
+type jobResource struct {
+	Metadata struct {
+		GUID string `json:"guid"`
+		URL  string `json:"url"`
+	} `json:"metadata"`
+	Entity struct {
+		Status       string `json:"status"`
+		ErrorDetails struct {
+			Description string `json:"description"`
+		} `json:"error_details"`
+	} `json:"entity"`
+}
+
+// DeleteOrg deletes an org and everything in it using a recursive, asynchronous V2 delete,
+// then waits for the resulting job to finish, polling it every pollInterval
+// An org which has already been deleted is not an error
+func DeleteOrg(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, orgGUID string, pollInterval time.Duration) error {
+	logger.Debug("deleting-org", lager.Data{
+		"guid": orgGUID,
+	})
+
+	var (
+		job *jobResource
+		err error
+	)
+	operation := func() error {
+		job, err = startOrgDelete(ctx, cfClient, orgGUID)
+		if internal.HasErrorCode(err, internal.OrganizationNotFound) {
+			logger.Debug("org-already-deleted")
+			job, err = nil, nil
+		}
+
+		return err
+	}
+
+	err = retryNotify(ctx, operation, func(err error, step time.Duration) {
+		logger.Error("failed-to-delete-org", err, lager.Data{
+			"backoff.step": step.String(),
+		})
+	})
+	if err != nil {
+		logger.Error("finally-failed-to-delete-org", err)
+		return err
+	}
+
+	if job == nil {
+		return nil
+	}
+
+	err = WaitForJob(ctx, logger, cfClient, job.Metadata.URL, pollInterval)
+	if err != nil {
+		logger.Error("failed-to-wait-for-delete-org-job", err)
+	}
+	return err
+}
+
+func startOrgDelete(ctx context.Context, cfClient *cfclient.Client, orgGUID string) (*jobResource, error) {
+	if err := wait(ctx, OperationDeleteOrg); err != nil {
+		return nil, err
+	}
+
+	req := cfClient.NewRequest("DELETE", fmt.Sprintf("/v2/organizations/%s?recursive=true&async=true", orgGUID))
+	resp, err := cfClient.DoRequest(req)
+	if err != nil {
+		return nil, internal.ResponseError(resp, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusAccepted:
+		var job jobResource
+		err = json.NewDecoder(resp.Body).Decode(&job)
+		if err != nil {
+			return nil, err
+		}
+		return &job, nil
+	case http.StatusNoContent:
+		return nil, nil
+	default:
+		return nil, &internal.StatusError{StatusCode: resp.StatusCode}
+	}
+}
+
+// WaitForJob polls the V2 job at jobURL every pollInterval until it has finished,
+// returning an error if the job fails or ctx is done first
+func WaitForJob(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, jobURL string, pollInterval time.Duration) error {
+	logger = logger.Session("wait-for-job", lager.Data{
+		"url": jobURL,
+	})
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := getJob(ctx, cfClient, jobURL)
+		if err != nil {
+			// a transient failure to fetch the job is retried on the next tick
+			logger.Error("failed-to-get-job", err)
+		} else {
+			switch job.Entity.Status {
+			case JobStatusFinished:
+				logger.Debug("finished")
+				return nil
+			case JobStatusFailed:
+				return fmt.Errorf("job %s failed: %s", job.Metadata.GUID, job.Entity.ErrorDetails.Description)
+			}
+
+			logger.Debug("polling", lager.Data{
+				"status": job.Entity.Status,
+			})
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func getJob(ctx context.Context, cfClient *cfclient.Client, jobURL string) (*jobResource, error) {
+	if err := wait(ctx, OperationGetJob); err != nil {
+		return nil, err
+	}
+
+	resp, err := cfClient.DoRequest(cfClient.NewRequest("GET", jobURL))
+	if err != nil {
+		return nil, internal.ResponseError(resp, err)
+	}
+	defer resp.Body.Close()
+
+	var job jobResource
+	err = json.NewDecoder(resp.Body).Decode(&job)
+	if err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
@@ -0,0 +1,94 @@
+package cf_test
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/pivotal-cf/perm-test/cf"
+)
+
+var _ = Describe("DeleteOrg", func() {
+	var (
+		server *ghttp.Server
+
+		cfClient *cfclient.Client
+		logger   *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/info"),
+			ghttp.RespondWith(200, "{}", nil),
+		))
+
+		var err error
+		cfClient, err = cfclient.NewClient(&cfclient.Config{
+			ApiAddress: "http://" + server.Addr(),
+			Token:      "foobar",
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("delete-org")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("recursively deletes the org and waits for the job to finish", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("DELETE", "/v2/organizations/some-org-guid", "recursive=true&async=true"),
+				ghttp.RespondWith(202, `{"metadata": {"guid": "some-job-guid", "url": "/v2/jobs/some-job-guid"}, "entity": {"status": "queued"}}`, nil),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/jobs/some-job-guid"),
+				ghttp.RespondWith(200, `{"metadata": {"guid": "some-job-guid"}, "entity": {"status": "running"}}`, nil),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/jobs/some-job-guid"),
+				ghttp.RespondWith(200, `{"metadata": {"guid": "some-job-guid"}, "entity": {"status": "finished"}}`, nil),
+			),
+		)
+
+		err := DeleteOrg(context.Background(), logger, cfClient, "some-org-guid", time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(server.ReceivedRequests()).To(HaveLen(4))
+	})
+
+	It("returns an error if the job fails", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("DELETE", "/v2/organizations/some-org-guid"),
+				ghttp.RespondWith(202, `{"metadata": {"guid": "some-job-guid", "url": "/v2/jobs/some-job-guid"}, "entity": {"status": "queued"}}`, nil),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/jobs/some-job-guid"),
+				ghttp.RespondWith(200, `{"metadata": {"guid": "some-job-guid"}, "entity": {"status": "failed", "error_details": {"description": "boom"}}}`, nil),
+			),
+		)
+
+		err := DeleteOrg(context.Background(), logger, cfClient, "some-org-guid", time.Millisecond)
+		Expect(err).To(MatchError(ContainSubstring("boom")))
+	})
+
+	It("succeeds if the org has already been deleted", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("DELETE", "/v2/organizations/some-org-guid"),
+			ghttp.RespondWith(404, `{"code": 30003, "error_code": "CF-OrganizationNotFound", "description": "The organization could not be found"}`, nil),
+		))
+
+		err := DeleteOrg(context.Background(), logger, cfClient, "some-org-guid", time.Millisecond)
+		Expect(err).NotTo(HaveOccurred())
+	})
+})
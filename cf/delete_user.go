@@ -0,0 +1,52 @@
+package cf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/cloudfoundry-community/go-cfclient"
+	"github.com/pivotal-cf/perm-test/cf/internal"
+)
+
+// DeleteUser deletes a user from Cloud Controller using the V2 API
+// It uses an exponential backoff strategy, and a user which has already been deleted is not an error
+func DeleteUser(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, userGUID string) error {
+	logger.Debug("deleting-user", lager.Data{
+		"guid": userGUID,
+	})
+
+	operation := func() error {
+		if err := wait(ctx, OperationDeleteUser); err != nil {
+			return err
+		}
+
+		resp, err := cfClient.DoRequest(cfClient.NewRequest("DELETE", fmt.Sprintf("/v2/users/%s", userGUID)))
+		if internal.HasErrorCode(err, internal.UserNotFound) {
+			logger.Debug("user-already-deleted")
+			return nil
+		}
+		if err != nil {
+			return internal.ResponseError(resp, err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusNoContent {
+			return &internal.StatusError{StatusCode: resp.StatusCode}
+		}
+
+		return nil
+	}
+
+	err := retryNotify(ctx, operation, func(err error, step time.Duration) {
+		logger.Error("failed-to-delete-user", err, lager.Data{
+			"backoff.step": step.String(),
+		})
+	})
+	if err != nil {
+		logger.Error("finally-failed-to-delete-user", err)
+	}
+	return err
+}
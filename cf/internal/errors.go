@@ -1,7 +1,230 @@
 package internal
 
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-community/go-cfclient"
+	"github.com/pkg/errors"
+)
+
 const (
+	UaaIDTaken            = "20002"
+	UserNotFound          = "20003"
 	OrganizationNameTaken = "30002"
+	OrganizationNotFound  = "30003"
 	SpaceNameTaken        = "40002"
 	AppNameTaken          = "100002"
+	UnprocessableEntity   = "10008"
 )
+
+// ErrorClass is how a Cloud Foundry error should be handled by a request which fails with it
+type ErrorClass int
+
+const (
+	// Retryable errors may succeed if the request is made again
+	Retryable ErrorClass = iota
+	// AlreadyExists errors mean the resource being created already exists
+	AlreadyExists
+	// Permanent errors will fail however many times the request is made,
+	// usually because the config or the client's permissions are wrong
+	Permanent
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case AlreadyExists:
+		return "already-exists"
+	case Permanent:
+		return "permanent"
+	default:
+		return "retryable"
+	}
+}
+
+// KnownError is an entry in the catalogue of Cloud Foundry errors
+type KnownError struct {
+	Name  string
+	Class ErrorClass
+
+	// Hint, for permanent errors, suggests what needs to be fixed
+	Hint string
+}
+
+// Catalogue classifies the Cloud Foundry errors, by numeric code, which seeding may encounter
+//
+// V2 and V3 errors share codes. Errors which are not in the catalogue are treated as retryable.
+var Catalogue = map[int]KnownError{
+	1000: {"CF-InvalidAuthToken", Permanent, "the client's token was rejected, check client_id and client_secret"},
+	1001: {"CF-MessageParseError", Permanent, "the request body was malformed"},
+	1002: {"CF-InvalidRelation", Permanent, "the request refers to a resource which does not exist or cannot be related"},
+
+	10001: {"CF-ServerError", Retryable, ""},
+	10002: {"CF-NotAuthenticated", Permanent, "the client is not authenticated, check client_id and client_secret"},
+	10003: {"CF-NotAuthorized", Permanent, "the client is not authorized, check it has the cloud_controller.admin authority"},
+	10007: {"CF-InsufficientScope", Permanent, "the client's token lacks a required scope, check its authorities"},
+	10008: {"CF-UnprocessableEntity", Permanent, "the request was invalid"},
+	10010: {"CF-ResourceNotFound", Permanent, "the resource does not exist"},
+	10011: {"CF-DatabaseError", Retryable, ""},
+	10013: {"CF-RateLimitExceeded", Retryable, ""},
+	10015: {"CF-ServiceUnavailable", Retryable, ""},
+
+	20002: {"CF-UaaIdTaken", AlreadyExists, ""},
+	20003: {"CF-UserNotFound", Permanent, "the user does not exist"},
+
+	30001: {"CF-OrganizationInvalid", Permanent, "the org is invalid, check the org quota and names"},
+	30002: {"CF-OrganizationNameTaken", AlreadyExists, ""},
+	30003: {"CF-OrganizationNotFound", Permanent, "the org does not exist"},
+
+	40001: {"CF-SpaceInvalid", Permanent, "the space is invalid, check the org's space quota"},
+	40002: {"CF-SpaceNameTaken", AlreadyExists, ""},
+	40003: {"CF-SpaceUserNotInOrg", Permanent, "the user must be a member of the space's org first"},
+	40004: {"CF-SpaceNotFound", Permanent, "the space does not exist"},
+
+	100001: {"CF-AppInvalid", Permanent, "the app is invalid"},
+	100002: {"CF-AppNameTaken", AlreadyExists, ""},
+	100004: {"CF-AppNotFound", Permanent, "the app does not exist"},
+	100005: {"CF-AppMemoryQuotaExceeded", Permanent, "the org's memory quota is exhausted, raise it or create fewer apps"},
+}
+
+// Classify returns the catalogue entry of the first Cloud Foundry error in err which is in the catalogue
+func Classify(err error) (KnownError, bool) {
+	for _, code := range ErrorCodes(err) {
+		if known, ok := Catalogue[code]; ok {
+			return known, true
+		}
+	}
+
+	return KnownError{}, false
+}
+
+// Describe returns the code, name and description of each Cloud Foundry error in err,
+// which cfclient leaves out of its error messages, or err's message if it has none
+func Describe(err error) string {
+	var descriptions []string
+	switch e := errors.Cause(err).(type) {
+	case cfclient.CloudFoundryError:
+		descriptions = append(descriptions, fmt.Sprintf("%s (%d): %s", e.ErrorCode, e.Code, e.Description))
+	case cfclient.CloudFoundryErrors:
+		for _, cfError := range e.Errors {
+			descriptions = append(descriptions, fmt.Sprintf("%s (%d): %s", cfError.ErrorCode, cfError.Code, cfError.Description))
+		}
+	case cfclient.V3CloudFoundryErrors:
+		for _, cfError := range e.Errors {
+			descriptions = append(descriptions, fmt.Sprintf("%s (%d): %s", cfError.Title, cfError.Code, cfError.Detail))
+		}
+	}
+
+	if len(descriptions) == 0 {
+		return err.Error()
+	}
+	return strings.Join(descriptions, "; ")
+}
+
+// IsAlreadyExists returns true if err says the resource being created already exists
+//
+// The V3 API returns CF-UnprocessableEntity for these rather than a code of their own,
+// so they are recognised by their description.
+func IsAlreadyExists(err error) bool {
+	if known, ok := Classify(err); ok && known.Class == AlreadyExists {
+		return true
+	}
+	if !HasErrorCode(err, UnprocessableEntity) {
+		return false
+	}
+
+	description := strings.ToLower(Describe(err))
+	for _, phrase := range []string{"already exists", "already has", "already bound", "must be unique", "is taken"} {
+		if strings.Contains(description, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInProgress returns true if err says the request conflicts with an asynchronous operation
+// which has not finished, such as binding a service instance which is still being created
+func IsInProgress(err error) bool {
+	return HasErrorCode(err, UnprocessableEntity) && strings.Contains(strings.ToLower(Describe(err)), "in progress")
+}
+
+// NotRetriedError is a failed request which was not retried, and the reason why
+type NotRetriedError struct {
+	Err    error
+	Reason string
+}
+
+func (e *NotRetriedError) Error() string {
+	return fmt.Sprintf("%s, not retrying: %s", Describe(e.Err), e.Reason)
+}
+
+// Cause returns the error the request failed with, so that errors.Cause and HasErrorCode see through it
+func (e *NotRetriedError) Cause() error {
+	return e.Err
+}
+
+// HasErrorCode returns true if err is, or contains, a V2 or V3 Cloud Foundry error
+// with the numeric code (e.g. 30002 for CF-OrganizationNameTaken)
+func HasErrorCode(err error, code string) bool {
+	for _, c := range ErrorCodes(err) {
+		if strconv.Itoa(c) == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ErrorCodes returns the numeric codes of the Cloud Foundry errors err is, or contains
+func ErrorCodes(err error) []int {
+	var codes []int
+	switch e := errors.Cause(err).(type) {
+	case cfclient.CloudFoundryError:
+		codes = append(codes, e.Code)
+	case cfclient.CloudFoundryErrors:
+		for _, cfError := range e.Errors {
+			codes = append(codes, cfError.Code)
+		}
+	case cfclient.V3CloudFoundryErrors:
+		for _, cfError := range e.Errors {
+			codes = append(codes, cfError.Code)
+		}
+	}
+
+	return codes
+}
+
+// StatusError is a response with an unexpected HTTP status code,
+// or whose body could not be read, in which case Err is the reason
+type StatusError struct {
+	StatusCode int
+	Err        error
+}
+
+func (e *StatusError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s (status code %d)", e.Err, e.StatusCode)
+	}
+	return fmt.Sprintf("Incorrect status code (%d)", e.StatusCode)
+}
+
+// ResponseError attaches the status code of resp to the error returned with it by cfclient.Client.DoRequest
+//
+// DoRequest returns the response alongside the error when a failed response's body is not
+// a Cloud Foundry error, e.g. a 502 from the router. Otherwise err is returned unchanged.
+func ResponseError(resp *http.Response, err error) error {
+	if err != nil && resp != nil {
+		return &StatusError{StatusCode: resp.StatusCode, Err: err}
+	}
+	return err
+}
+
+// StatusCode returns the HTTP status code of err, if it is a *StatusError
+func StatusCode(err error) (int, bool) {
+	if e, ok := errors.Cause(err).(*StatusError); ok {
+		return e.StatusCode, true
+	}
+	return 0, false
+}
@@ -0,0 +1,58 @@
+package cf
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/cloudfoundry-community/go-cfclient"
+)
+
+// ListOrgs returns every org visible to the client, following all pages of /v2/organizations
+//
+// Each page is requested, rate limited and retried on its own, as WalkV2 does.
+func ListOrgs(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client) ([]cfclient.Org, error) {
+	logger.Debug("listing-orgs")
+
+	var orgs []cfclient.Org
+	for path := "/v2/organizations"; path != ""; {
+		var page cfclient.OrgResponse
+		err := getPage(ctx, logger, cfClient, path, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range page.Resources {
+			org := r.Entity
+			org.Guid = r.Meta.Guid
+			orgs = append(orgs, org)
+		}
+		path = page.NextUrl
+	}
+
+	return orgs, nil
+}
+
+// ListUsers returns every user visible to the client, following all pages of /v2/users
+//
+// Each page is requested, rate limited and retried on its own, as WalkV2 does.
+func ListUsers(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client) ([]cfclient.User, error) {
+	logger.Debug("listing-users")
+
+	var users []cfclient.User
+	for path := "/v2/users"; path != ""; {
+		var page cfclient.UserResponse
+		err := getPage(ctx, logger, cfClient, path, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range page.Resources {
+			user := r.Entity
+			user.Guid = r.Meta.Guid
+			users = append(users, user)
+		}
+		path = page.NextUrl
+	}
+
+	return users, nil
+}
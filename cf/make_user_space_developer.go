@@ -1,33 +1,38 @@
 package cf
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"time"
 
 	"code.cloudfoundry.org/lager"
-	"github.com/cenkalti/backoff"
 	"github.com/cloudfoundry-community/go-cfclient"
+	"github.com/pivotal-cf/perm-test/cf/internal"
 )
 
-func MakeUserSpaceDeveloper(logger lager.Logger, cfClient *cfclient.Client, userGUID string, spaceGUID string) error {
+func MakeUserSpaceDeveloper(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, userGUID string, spaceGUID string) error {
 	logger.Debug("making-user-space-developer")
 	r := cfClient.NewRequest("PUT", fmt.Sprintf("/v2/spaces/%s/developers/%s", spaceGUID, userGUID))
 	operation := func() error {
+		if err := wait(ctx, OperationMakeUserSpaceDeveloper); err != nil {
+			return err
+		}
+
 		resp, err := cfClient.DoRequest(r)
 
 		if err != nil {
-			return err
+			return internal.ResponseError(resp, err)
 		}
 
 		if resp.StatusCode != http.StatusCreated {
-			err = fmt.Errorf("Incorrect status code (%d)", resp.StatusCode)
+			err = &internal.StatusError{StatusCode: resp.StatusCode}
 			return err
 		}
 
 		return nil
 	}
-	err := backoff.RetryNotify(operation, backoff.NewExponentialBackOff(), func(err error, step time.Duration) {
+	err := retryNotify(ctx, operation, func(err error, step time.Duration) {
 		logger.Error("failed-to-make-user-space-developer", err, lager.Data{
 			"backoff.step": step.String(),
 		})
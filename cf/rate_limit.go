@@ -0,0 +1,97 @@
+package cf
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cenkalti/backoff"
+)
+
+// Names of the operations performed by this package, by which requests are rate limited
+const (
+	OperationCreateOrg                  = "create_org"
+	OperationCreateSpace                = "create_space"
+	OperationCreateApp                  = "create_app"
+	OperationCreateUser                 = "create_user"
+	OperationCreateRoute                = "create_route"
+	OperationCreateServiceInstance      = "create_service_instance"
+	OperationBindService                = "bind_service"
+	OperationUpdateApp                  = "update_app"
+	OperationAssociateUserWithOrg       = "associate_user_with_org"
+	OperationAssociateOrgManager        = "associate_org_manager"
+	OperationAssociateOrgBillingManager = "associate_org_billing_manager"
+	OperationAssociateOrgAuditor        = "associate_org_auditor"
+	OperationMakeUserSpaceDeveloper     = "make_user_space_developer"
+	OperationMakeUserSpaceManager       = "make_user_space_manager"
+	OperationMakeUserSpaceAuditor       = "make_user_space_auditor"
+	OperationDeleteOrg                  = "delete_org"
+	OperationDeleteUser                 = "delete_user"
+	OperationGetJob                     = "get_job"
+	OperationList                       = "list"
+	OperationCount                      = "count"
+)
+
+var Operations = []string{
+	OperationCreateOrg,
+	OperationCreateSpace,
+	OperationCreateApp,
+	OperationCreateUser,
+	OperationCreateRoute,
+	OperationCreateServiceInstance,
+	OperationBindService,
+	OperationUpdateApp,
+	OperationAssociateUserWithOrg,
+	OperationAssociateOrgManager,
+	OperationAssociateOrgBillingManager,
+	OperationAssociateOrgAuditor,
+	OperationMakeUserSpaceDeveloper,
+	OperationMakeUserSpaceManager,
+	OperationMakeUserSpaceAuditor,
+	OperationDeleteOrg,
+	OperationDeleteUser,
+	OperationGetJob,
+	OperationList,
+	OperationCount,
+}
+
+// RateLimiter paces the requests made to Cloud Controller
+type RateLimiter interface {
+	// Wait blocks until a request for the operation may be made,
+	// returning an error if ctx is done first
+	Wait(ctx context.Context, operation string) error
+}
+
+var (
+	rateLimiterMu sync.RWMutex
+	rateLimiter   RateLimiter
+)
+
+// SetRateLimiter makes every function in this package wait for l before each
+// request it makes, including those retried after a backoff
+//
+// A nil limiter removes the limit
+func SetRateLimiter(l RateLimiter) {
+	rateLimiterMu.Lock()
+	defer rateLimiterMu.Unlock()
+
+	rateLimiter = l
+}
+
+// wait blocks until the limiter allows a request for the operation
+//
+// If ctx is done first the error is permanent, so that a backoff retrying the request stops.
+func wait(ctx context.Context, operation string) error {
+	rateLimiterMu.RLock()
+	l := rateLimiter
+	rateLimiterMu.RUnlock()
+
+	if l == nil {
+		return nil
+	}
+
+	err := l.Wait(ctx, operation)
+	if err != nil {
+		return backoff.Permanent(err)
+	}
+	return nil
+}
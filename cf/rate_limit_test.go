@@ -0,0 +1,123 @@
+package cf_test
+
+import (
+	"context"
+	"sync"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/pivotal-cf/perm-test/cf"
+)
+
+var _ = Describe("SetRateLimiter", func() {
+	var (
+		server *ghttp.Server
+
+		cfClient *cfclient.Client
+		logger   *lagertest.TestLogger
+		limiter  *fakeRateLimiter
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/info"),
+			ghttp.RespondWith(200, "{}", nil),
+		))
+
+		var err error
+		cfClient, err = cfclient.NewClient(&cfclient.Config{
+			ApiAddress: "http://" + server.Addr(),
+			Token:      "foobar",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("rate-limit")
+
+		limiter = &fakeRateLimiter{}
+		SetRateLimiter(limiter)
+	})
+
+	AfterEach(func() {
+		SetRateLimiter(nil)
+		server.Close()
+	})
+
+	It("waits for the limiter before every request, including retries", func() {
+		server.AppendHandlers(
+			ghttp.RespondWith(500, "", nil),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/v2/spaces/some-space-guid/developers/some-user-guid"),
+				ghttp.RespondWith(201, "{}", nil),
+			),
+		)
+
+		err := MakeUserSpaceDeveloper(context.Background(), logger, cfClient, "some-user-guid", "some-space-guid")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(limiter.operations).To(Equal([]string{OperationMakeUserSpaceDeveloper, OperationMakeUserSpaceDeveloper}))
+	})
+
+	It("waits for each request made to create an org which already exists", func() {
+		server.AppendHandlers(
+			ghttp.RespondWith(400, `{"code": 30002, "error_code": "CF-OrganizationNameTaken"}`, nil),
+			ghttp.RespondWith(200, `{"resources": [{"metadata": {"guid": "some-org-guid"}, "entity": {"name": "some-org"}}]}`, nil),
+		)
+
+		_, err := CreateOrgIfNotExists(context.Background(), logger, cfClient, "some-org")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(limiter.operations).To(Equal([]string{OperationCreateOrg, OperationCreateOrg}))
+	})
+
+	It("waits before each page of a list", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/organizations"),
+				ghttp.RespondWith(200, `{"next_url": "/v2/organizations?page=2", "resources": [{"metadata": {"guid": "org-guid-1"}, "entity": {"name": "org-1"}}]}`, nil),
+			),
+			ghttp.RespondWith(500, "", nil),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/organizations", "page=2"),
+				ghttp.RespondWith(200, `{"resources": [{"metadata": {"guid": "org-guid-2"}, "entity": {"name": "org-2"}}]}`, nil),
+			),
+		)
+
+		orgs, err := ListOrgs(context.Background(), logger, cfClient)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(orgs).To(HaveLen(2))
+		Expect(orgs[0].Guid).To(Equal("org-guid-1"))
+		Expect(orgs[1].Name).To(Equal("org-2"))
+		Expect(limiter.operations).To(Equal([]string{OperationList, OperationList, OperationList}))
+	})
+
+	It("stops retrying once the context is done while waiting", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := MakeUserSpaceDeveloper(ctx, logger, cfClient, "some-user-guid", "some-space-guid")
+		Expect(err).To(Equal(context.Canceled))
+
+		Expect(limiter.operations).To(Equal([]string{OperationMakeUserSpaceDeveloper}))
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+})
+
+type fakeRateLimiter struct {
+	mu         sync.Mutex
+	operations []string
+}
+
+func (l *fakeRateLimiter) Wait(ctx context.Context, operation string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.operations = append(l.operations, operation)
+	return ctx.Err()
+}
@@ -0,0 +1,149 @@
+package cf
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"github.com/pivotal-cf/perm-test/cf/internal"
+	"github.com/pivotal-cf/perm-test/retries"
+)
+
+// RetryPolicy is how every function in this package retries a failed request
+//
+// Requests are retried with exponential backoff and jitter until MaxElapsedTime has passed.
+// A response with an HTTP status code is only retried if the code is one of RetryableStatusCodes,
+// and a Cloud Foundry error only if its code is one of RetryableErrorCodes, or either list is empty.
+// Without RetryableErrorCodes, Cloud Foundry errors which the internal catalogue classifies as
+// already-exists or permanent are never retried, unless they say an asynchronous operation
+// is still in progress, and unknown ones are.
+// Errors without a response, such as timeouts, are always retried.
+type RetryPolicy struct {
+	InitialInterval     time.Duration
+	MaxInterval         time.Duration
+	Multiplier          float64
+	RandomizationFactor float64
+	MaxElapsedTime      time.Duration
+
+	RetryableStatusCodes []int
+	RetryableErrorCodes  []int
+}
+
+// DefaultRetryPolicy retries with the backoff package's defaults, for up to 15 minutes,
+// timeouts, responses with one of the retryable status codes (throttling and server errors),
+// Cloud Foundry errors the catalogue does not classify as already-exists or permanent,
+// and errors saying an asynchronous operation is still in progress
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		InitialInterval:      backoff.DefaultInitialInterval,
+		MaxInterval:          backoff.DefaultMaxInterval,
+		Multiplier:           backoff.DefaultMultiplier,
+		RandomizationFactor:  backoff.DefaultRandomizationFactor,
+		MaxElapsedTime:       backoff.DefaultMaxElapsedTime,
+		RetryableStatusCodes: []int{408, 429, 500, 502, 503, 504},
+	}
+}
+
+// Retryable returns true if the policy retries a request which failed with err
+func (p RetryPolicy) Retryable(err error) bool {
+	retryable, _ := p.classify(err)
+	return retryable
+}
+
+// classify returns whether the policy retries a request which failed with err and, if not, why not
+func (p RetryPolicy) classify(err error) (bool, string) {
+	if code, ok := internal.StatusCode(err); ok && len(p.RetryableStatusCodes) > 0 {
+		if containsCode(p.RetryableStatusCodes, code) {
+			return true, ""
+		}
+		return false, fmt.Sprintf("status code %d is not one of the retryable status codes", code)
+	}
+
+	codes := internal.ErrorCodes(err)
+	if len(codes) > 0 && len(p.RetryableErrorCodes) > 0 {
+		for _, code := range codes {
+			if containsCode(p.RetryableErrorCodes, code) {
+				return true, ""
+			}
+		}
+		return false, "error code is not one of the retryable error codes"
+	}
+
+	if internal.IsInProgress(err) {
+		return true, ""
+	}
+
+	if known, ok := internal.Classify(err); ok {
+		switch known.Class {
+		case internal.AlreadyExists:
+			return false, fmt.Sprintf("%s means the resource already exists", known.Name)
+		case internal.Permanent:
+			return false, fmt.Sprintf("%s is permanent, %s", known.Name, known.Hint)
+		}
+	}
+
+	return true, ""
+}
+
+// NewBackOff returns an exponential backoff with the policy's intervals, multiplier, jitter and elapsed time,
+// so that requests to other services can be retried in the same way
+func (p RetryPolicy) NewBackOff() backoff.BackOff {
+	b := backoff.NewExponentialBackOff()
+	b.InitialInterval = p.InitialInterval
+	b.MaxInterval = p.MaxInterval
+	b.Multiplier = p.Multiplier
+	b.RandomizationFactor = p.RandomizationFactor
+	b.MaxElapsedTime = p.MaxElapsedTime
+	b.Reset()
+	return b
+}
+
+func containsCode(codes []int, code int) bool {
+	for _, c := range codes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	retryPolicyMu sync.RWMutex
+	retryPolicy   = DefaultRetryPolicy()
+)
+
+// SetRetryPolicy makes every function in this package retry failed requests according to p
+func SetRetryPolicy(p RetryPolicy) {
+	retryPolicyMu.Lock()
+	defer retryPolicyMu.Unlock()
+
+	retryPolicy = p
+}
+
+// retryNotify is backoff.RetryNotify with the retry policy, which stops retrying
+// once ctx is done or the operation fails with an error the policy does not retry,
+// returning that error as an *internal.NotRetriedError which explains why.
+// Each retry is reported to the retries.Observer of ctx, if it has one
+func retryNotify(ctx context.Context, operation backoff.Operation, notify backoff.Notify) error {
+	retryPolicyMu.RLock()
+	p := retryPolicy
+	retryPolicyMu.RUnlock()
+
+	classified := func() error {
+		err := operation()
+		if err == nil {
+			return nil
+		}
+		if _, ok := err.(*backoff.PermanentError); ok {
+			return err
+		}
+		if retryable, reason := p.classify(err); !retryable {
+			return backoff.Permanent(&internal.NotRetriedError{Err: err, Reason: reason})
+		}
+		return err
+	}
+
+	return backoff.RetryNotify(classified, backoff.WithContext(p.NewBackOff(), ctx), retries.Notify(ctx, notify))
+}
@@ -0,0 +1,185 @@
+package cf_test
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/pivotal-cf/perm-test/retries"
+
+	. "github.com/pivotal-cf/perm-test/cf"
+)
+
+var _ = Describe("SetRetryPolicy", func() {
+	var (
+		server *ghttp.Server
+
+		cfClient *cfclient.Client
+		logger   *lagertest.TestLogger
+		policy   RetryPolicy
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/info"),
+			ghttp.RespondWith(200, "{}", nil),
+		))
+
+		var err error
+		cfClient, err = cfclient.NewClient(&cfclient.Config{
+			ApiAddress: "http://" + server.Addr(),
+			Token:      "foobar",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("retry")
+
+		policy = DefaultRetryPolicy()
+		policy.InitialInterval = time.Millisecond
+		policy.MaxElapsedTime = time.Second
+	})
+
+	JustBeforeEach(func() {
+		SetRetryPolicy(policy)
+	})
+
+	AfterEach(func() {
+		SetRetryPolicy(DefaultRetryPolicy())
+		server.Close()
+	})
+
+	It("retries responses with a retryable status code", func() {
+		server.AppendHandlers(
+			ghttp.RespondWith(503, "Service Unavailable", nil),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("PUT", "/v2/spaces/some-space-guid/developers/some-user-guid"),
+				ghttp.RespondWith(201, "{}", nil),
+			),
+		)
+
+		err := MakeUserSpaceDeveloper(context.Background(), logger, cfClient, "some-user-guid", "some-space-guid")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("reports each retry to the observer of the context", func() {
+		server.AppendHandlers(
+			ghttp.RespondWith(503, "Service Unavailable", nil),
+			ghttp.RespondWith(201, "{}", nil),
+		)
+
+		var steps []time.Duration
+		ctx := retries.WithObserver(context.Background(), func(step time.Duration) {
+			steps = append(steps, step)
+		})
+
+		err := MakeUserSpaceDeveloper(ctx, logger, cfClient, "some-user-guid", "some-space-guid")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(steps).To(HaveLen(1))
+	})
+
+	It("does not retry responses with other status codes", func() {
+		server.AppendHandlers(
+			ghttp.RespondWith(404, "Not Found", nil),
+		)
+
+		err := MakeUserSpaceDeveloper(context.Background(), logger, cfClient, "some-user-guid", "some-space-guid")
+		Expect(err).To(MatchError(ContainSubstring("status code 404")))
+		Expect(server.ReceivedRequests()).To(HaveLen(2))
+	})
+
+	It("gives up once the maximum elapsed time has passed", func() {
+		policy.MaxElapsedTime = 50 * time.Millisecond
+		policy.MaxInterval = 10 * time.Millisecond
+		server.AllowUnhandledRequests = true
+		server.UnhandledRequestStatusCode = 503
+
+		err := MakeUserSpaceDeveloper(context.Background(), logger, cfClient, "some-user-guid", "some-space-guid")
+		Expect(err).To(HaveOccurred())
+		Expect(len(server.ReceivedRequests())).To(BeNumerically(">", 2))
+	})
+
+	Context("when the retryable error codes are restricted", func() {
+		BeforeEach(func() {
+			policy.RetryableErrorCodes = []int{10001}
+		})
+
+		It("retries only those Cloud Foundry errors", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(500, `{"code": 10001, "error_code": "CF-ServerError", "description": "boom"}`, nil),
+				ghttp.RespondWith(400, `{"code": 30003, "error_code": "CF-OrganizationNotFound", "description": "not found"}`, nil),
+			)
+
+			_, err := CreateOrgIfNotExists(context.Background(), logger, cfClient, "some-org")
+			Expect(err).To(MatchError(ContainSubstring("30003")))
+			Expect(server.ReceivedRequests()).To(HaveLen(3))
+		})
+	})
+
+	It("fails fast on Cloud Foundry errors which are permanent", func() {
+		server.AppendHandlers(
+			ghttp.RespondWith(403, `{"code": 10003, "error_code": "CF-NotAuthorized", "description": "You are not authorized to perform the requested action"}`, nil),
+		)
+
+		_, err := CreateOrgIfNotExists(context.Background(), logger, cfClient, "some-org")
+		Expect(err).To(MatchError(And(
+			ContainSubstring("CF-NotAuthorized (10003): You are not authorized"),
+			ContainSubstring("not retrying"),
+			ContainSubstring("cloud_controller.admin"),
+		)))
+		Expect(server.ReceivedRequests()).To(HaveLen(2))
+	})
+
+	It("retries Cloud Foundry errors which are not in the catalogue", func() {
+		server.AppendHandlers(
+			ghttp.RespondWith(500, `{"code": 99999, "error_code": "CF-SomethingNew", "description": "boom"}`, nil),
+			ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v2/organizations"),
+				ghttp.RespondWith(201, `{"metadata": {"guid": "some-org-guid"}, "entity": {"name": "some-org"}}`, nil),
+			),
+		)
+
+		org, err := CreateOrgIfNotExists(context.Background(), logger, cfClient, "some-org")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(org.Guid).To(Equal("some-org-guid"))
+	})
+
+	Describe("CreateUser", func() {
+		It("retries failures", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(502, "Bad Gateway", nil),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v2/users"),
+					ghttp.VerifyJSON(`{"guid": "some-user-guid"}`),
+					ghttp.RespondWith(201, `{"metadata": {"guid": "some-user-guid"}, "entity": {}}`, nil),
+				),
+			)
+
+			user, err := CreateUser(context.Background(), logger, cfClient, "some-user-guid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.Guid).To(Equal("some-user-guid"))
+		})
+
+		It("returns the existing user when the GUID is taken", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v2/users"),
+					ghttp.RespondWith(400, `{"code": 20002, "error_code": "CF-UaaIdTaken", "description": "The UAA ID is taken: some-user-guid"}`, nil),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/users/some-user-guid"),
+					ghttp.RespondWith(200, `{"metadata": {"guid": "some-user-guid"}, "entity": {}}`, nil),
+				),
+			)
+
+			user, err := CreateUser(context.Background(), logger, cfClient, "some-user-guid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(user.Guid).To(Equal("some-user-guid"))
+		})
+	})
+})
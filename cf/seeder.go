@@ -0,0 +1,158 @@
+package cf
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/cloudfoundry-community/go-cfclient"
+)
+
+//go:generate counterfeiter . Seeder
+
+// Seeder creates the orgs, spaces, apps and users which make up the dataset,
+// and assigns roles to the users
+//
+// Create operations succeed if the resource already exists, returning its GUID
+type Seeder interface {
+	CreateOrg(ctx context.Context, logger lager.Logger, name string) (string, error)
+	CreateSpace(ctx context.Context, logger lager.Logger, name string, orgGUID string) (string, error)
+	CreateApp(ctx context.Context, logger lager.Logger, name string, spaceGUID string) (string, error)
+	CreateUser(ctx context.Context, logger lager.Logger, userGUID string) error
+	AssociateUserWithOrg(ctx context.Context, logger lager.Logger, userGUID string, orgGUID string) error
+	AssociateOrgManager(ctx context.Context, logger lager.Logger, userGUID string, orgGUID string) error
+	AssociateOrgBillingManager(ctx context.Context, logger lager.Logger, userGUID string, orgGUID string) error
+	AssociateOrgAuditor(ctx context.Context, logger lager.Logger, userGUID string, orgGUID string) error
+	MakeUserSpaceDeveloper(ctx context.Context, logger lager.Logger, userGUID string, spaceGUID string) error
+	MakeUserSpaceManager(ctx context.Context, logger lager.Logger, userGUID string, spaceGUID string) error
+	MakeUserSpaceAuditor(ctx context.Context, logger lager.Logger, userGUID string, spaceGUID string) error
+}
+
+// CloudControllerSeeder seeds the dataset through the Cloud Controller API
+type CloudControllerSeeder struct {
+	cfClient *cfclient.Client
+
+	// V3 creates every resource and assigns every role with the V3 API rather than the V2 API
+	V3 bool
+
+	// V3Roles assigns roles with POST /v3/roles rather than the V2 association endpoints,
+	// even if V3 is not set
+	V3Roles bool
+
+	// AppShape is created with every app
+	AppShape AppShape
+}
+
+func NewCloudControllerSeeder(cfClient *cfclient.Client) *CloudControllerSeeder {
+	return &CloudControllerSeeder{
+		cfClient: cfClient,
+	}
+}
+
+func (s *CloudControllerSeeder) CreateOrg(ctx context.Context, logger lager.Logger, name string) (string, error) {
+	if s.V3 {
+		return CreateV3OrgIfNotExists(ctx, logger, s.cfClient, name)
+	}
+
+	org, err := CreateOrgIfNotExists(ctx, logger, s.cfClient, name)
+	if err != nil {
+		return "", err
+	}
+
+	return org.Guid, nil
+}
+
+func (s *CloudControllerSeeder) CreateSpace(ctx context.Context, logger lager.Logger, name string, orgGUID string) (string, error) {
+	if s.V3 {
+		return CreateV3SpaceIfNotExists(ctx, logger, s.cfClient, name, orgGUID)
+	}
+
+	space, err := CreateSpaceIfNotExists(ctx, logger, s.cfClient, name, orgGUID)
+	if err != nil {
+		return "", err
+	}
+
+	return space.Guid, nil
+}
+
+func (s *CloudControllerSeeder) CreateApp(ctx context.Context, logger lager.Logger, name string, spaceGUID string) (string, error) {
+	var (
+		guid string
+		err  error
+	)
+	if s.V3 {
+		guid, err = CreateV3AppIfNotExists(ctx, logger, s.cfClient, name, spaceGUID)
+	} else {
+		guid, err = CreateAppIfNotExists(ctx, logger, s.cfClient, name, spaceGUID)
+	}
+	if err != nil {
+		return "", err
+	}
+	if s.AppShape.IsZero() {
+		return guid, nil
+	}
+
+	err = ShapeApp(ctx, logger, s.cfClient, s.AppShape, name, guid, spaceGUID)
+	if err != nil {
+		return "", err
+	}
+
+	return guid, nil
+}
+
+func (s *CloudControllerSeeder) CreateUser(ctx context.Context, logger lager.Logger, userGUID string) error {
+	if s.V3 {
+		return CreateV3User(ctx, logger, s.cfClient, userGUID)
+	}
+
+	_, err := CreateUser(ctx, logger, s.cfClient, userGUID)
+	return err
+}
+
+func (s *CloudControllerSeeder) AssociateUserWithOrg(ctx context.Context, logger lager.Logger, userGUID string, orgGUID string) error {
+	if s.V3 || s.V3Roles {
+		return CreateRole(ctx, logger, s.cfClient, RoleTypeOrganizationUser, userGUID, orgGUID)
+	}
+	return AssociateUserWithOrg(ctx, logger, s.cfClient, userGUID, orgGUID)
+}
+
+func (s *CloudControllerSeeder) AssociateOrgManager(ctx context.Context, logger lager.Logger, userGUID string, orgGUID string) error {
+	if s.V3 || s.V3Roles {
+		return CreateRole(ctx, logger, s.cfClient, RoleTypeOrganizationManager, userGUID, orgGUID)
+	}
+	return AssociateOrgManager(ctx, logger, s.cfClient, userGUID, orgGUID)
+}
+
+func (s *CloudControllerSeeder) AssociateOrgBillingManager(ctx context.Context, logger lager.Logger, userGUID string, orgGUID string) error {
+	if s.V3 || s.V3Roles {
+		return CreateRole(ctx, logger, s.cfClient, RoleTypeOrganizationBillingManager, userGUID, orgGUID)
+	}
+	return AssociateOrgBillingManager(ctx, logger, s.cfClient, userGUID, orgGUID)
+}
+
+func (s *CloudControllerSeeder) AssociateOrgAuditor(ctx context.Context, logger lager.Logger, userGUID string, orgGUID string) error {
+	if s.V3 || s.V3Roles {
+		return CreateRole(ctx, logger, s.cfClient, RoleTypeOrganizationAuditor, userGUID, orgGUID)
+	}
+	return AssociateOrgAuditor(ctx, logger, s.cfClient, userGUID, orgGUID)
+}
+
+func (s *CloudControllerSeeder) MakeUserSpaceDeveloper(ctx context.Context, logger lager.Logger, userGUID string, spaceGUID string) error {
+	if s.V3 || s.V3Roles {
+		return CreateRole(ctx, logger, s.cfClient, RoleTypeSpaceDeveloper, userGUID, spaceGUID)
+	}
+	return MakeUserSpaceDeveloper(ctx, logger, s.cfClient, userGUID, spaceGUID)
+}
+
+func (s *CloudControllerSeeder) MakeUserSpaceManager(ctx context.Context, logger lager.Logger, userGUID string, spaceGUID string) error {
+	if s.V3 || s.V3Roles {
+		return CreateRole(ctx, logger, s.cfClient, RoleTypeSpaceManager, userGUID, spaceGUID)
+	}
+	return MakeUserSpaceManager(ctx, logger, s.cfClient, userGUID, spaceGUID)
+}
+
+func (s *CloudControllerSeeder) MakeUserSpaceAuditor(ctx context.Context, logger lager.Logger, userGUID string, spaceGUID string) error {
+	if s.V3 || s.V3Roles {
+		return CreateRole(ctx, logger, s.cfClient, RoleTypeSpaceAuditor, userGUID, spaceGUID)
+	}
+	return MakeUserSpaceAuditor(ctx, logger, s.cfClient, userGUID, spaceGUID)
+}
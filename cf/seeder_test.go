@@ -0,0 +1,137 @@
+package cf_test
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/pivotal-cf/perm-test/cf"
+)
+
+var _ = Describe("CloudControllerSeeder", func() {
+	var (
+		server *ghttp.Server
+
+		seeder *CloudControllerSeeder
+		logger *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/info"),
+			ghttp.RespondWith(200, "{}", nil),
+		))
+
+		cfClient, err := cfclient.NewClient(&cfclient.Config{
+			ApiAddress: "http://" + server.Addr(),
+			Token:      "foobar",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		seeder = NewCloudControllerSeeder(cfClient)
+		logger = lagertest.NewTestLogger("seeder")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("when V3 is set", func() {
+		BeforeEach(func() {
+			seeder.V3 = true
+		})
+
+		It("creates orgs with the V3 API", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v3/organizations"),
+				ghttp.VerifyJSON(`{"name": "some-org"}`),
+				ghttp.RespondWith(201, `{"guid": "some-org-guid", "name": "some-org"}`, nil),
+			))
+
+			guid, err := seeder.CreateOrg(context.Background(), logger, "some-org")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(guid).To(Equal("some-org-guid"))
+		})
+
+		It("returns the existing org if the name is taken", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("POST", "/v3/organizations"),
+					ghttp.RespondWith(422, `{"errors": [{"code": 10008, "title": "CF-UnprocessableEntity", "detail": "Organization 'some-org' already exists."}]}`, nil),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/organizations", "names=some-org"),
+					ghttp.RespondWith(200, `{"resources": [{"guid": "some-org-guid", "name": "some-org"}]}`, nil),
+				),
+			)
+
+			guid, err := seeder.CreateOrg(context.Background(), logger, "some-org")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(guid).To(Equal("some-org-guid"))
+		})
+
+		It("creates spaces in their org", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v3/spaces"),
+				ghttp.VerifyJSON(`{"name": "some-space", "relationships": {"organization": {"data": {"guid": "some-org-guid"}}}}`),
+				ghttp.RespondWith(201, `{"guid": "some-space-guid", "name": "some-space"}`, nil),
+			))
+
+			guid, err := seeder.CreateSpace(context.Background(), logger, "some-space", "some-org-guid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(guid).To(Equal("some-space-guid"))
+		})
+
+		It("returns the existing space if the name is taken", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(422, `{"errors": [{"code": 10008, "title": "CF-UnprocessableEntity", "detail": "Name must be unique per organization"}]}`, nil),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/spaces", "names=some-space&organization_guids=some-org-guid"),
+					ghttp.RespondWith(200, `{"resources": [{"guid": "some-space-guid"}]}`, nil),
+				),
+			)
+
+			guid, err := seeder.CreateSpace(context.Background(), logger, "some-space", "some-org-guid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(guid).To(Equal("some-space-guid"))
+		})
+
+		It("creates apps in their space", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v3/apps"),
+				ghttp.VerifyJSON(`{"name": "some-app", "relationships": {"space": {"data": {"guid": "some-space-guid"}}}}`),
+				ghttp.RespondWith(201, `{"guid": "some-app-guid"}`, nil),
+			))
+
+			guid, err := seeder.CreateApp(context.Background(), logger, "some-app", "some-space-guid")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(guid).To(Equal("some-app-guid"))
+		})
+
+		It("creates users, succeeding if the GUID is taken", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v3/users"),
+				ghttp.VerifyJSON(`{"guid": "some-user-guid"}`),
+				ghttp.RespondWith(422, `{"errors": [{"code": 10008, "title": "CF-UnprocessableEntity", "detail": "User with guid 'some-user-guid' already exists."}]}`, nil),
+			))
+
+			Expect(seeder.CreateUser(context.Background(), logger, "some-user-guid")).To(Succeed())
+		})
+
+		It("assigns roles with the V3 API", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/v3/roles"),
+				ghttp.VerifyJSON(`{"type": "space_developer", "relationships": {"user": {"data": {"guid": "some-user-guid"}}, "space": {"data": {"guid": "some-space-guid"}}}}`),
+				ghttp.RespondWith(201, `{"guid": "some-role-guid"}`, nil),
+			))
+
+			Expect(seeder.MakeUserSpaceDeveloper(context.Background(), logger, "some-user-guid", "some-space-guid")).To(Succeed())
+		})
+	})
+})
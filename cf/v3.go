@@ -0,0 +1,131 @@
+package cf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/cloudfoundry-community/go-cfclient"
+	"github.com/pivotal-cf/perm-test/cf/internal"
+)
+
+// createV3IfNotExists POSTs body to the V3 API at path, returning the GUID of the created resource
+// It uses an exponential backoff strategy, returning early if it successfully creates the resource
+// or the resource already exists, in which case the GUID found by lookup is returned.
+// lookup also finds the GUID of resources which are created asynchronously.
+//
+// resource names the resource in log messages, e.g. failed-to-create-<resource>
+func createV3IfNotExists(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, op string, resource string, path string, body interface{}, lookup func() (string, error)) (string, error) {
+	var guid string
+	operation := func() error {
+		if err := wait(ctx, op); err != nil {
+			return err
+		}
+
+		var err error
+		guid, err = postV3(cfClient, path, body)
+		if internal.IsAlreadyExists(err) {
+			logger.Debug(resource + "-already-exists")
+			err = nil
+		}
+		if err == nil && guid == "" {
+			if err := wait(ctx, op); err != nil {
+				return err
+			}
+
+			guid, err = lookup()
+		}
+
+		return err
+	}
+
+	err := retryNotify(ctx, operation, func(err error, step time.Duration) {
+		logger.Error("failed-to-create-"+resource, err, lager.Data{
+			"backoff.step": step.String(),
+		})
+	})
+	if err != nil {
+		logger.Error("finally-failed-to-create-"+resource, err)
+		return "", err
+	}
+
+	return guid, nil
+}
+
+// postV3 POSTs body to the V3 API at path, returning the GUID of the created resource,
+// or an empty GUID if it is being created asynchronously
+func postV3(cfClient *cfclient.Client, path string, body interface{}) (string, error) {
+	resp, err := requestV3(cfClient, "POST", path, body)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusAccepted {
+		return "", nil
+	}
+
+	var resource v3Resource
+	err = json.NewDecoder(resp.Body).Decode(&resource)
+	if err != nil {
+		return "", &internal.StatusError{StatusCode: resp.StatusCode, Err: err}
+	}
+
+	return resource.GUID, nil
+}
+
+// requestV3 sends body to the V3 API at path, returning the response if it is a 200, 201 or 202
+func requestV3(cfClient *cfclient.Client, method string, path string, body interface{}) (*http.Response, error) {
+	b := bytes.NewBuffer(nil)
+	err := json.NewEncoder(b).Encode(body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := cfClient.DoRequest(cfClient.NewRequestWithBody(method, path, b))
+	if err != nil {
+		return nil, internal.ResponseError(resp, err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusAccepted:
+		return resp, nil
+	default:
+		resp.Body.Close()
+		return nil, &internal.StatusError{StatusCode: resp.StatusCode}
+	}
+}
+
+// findV3 returns the GUID of the only resource listed by the V3 API at path
+func findV3(cfClient *cfclient.Client, path string) (string, error) {
+	resp, err := cfClient.DoRequest(cfClient.NewRequest("GET", path))
+	if err != nil {
+		return "", internal.ResponseError(resp, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &internal.StatusError{StatusCode: resp.StatusCode}
+	}
+
+	var list struct {
+		Resources []v3Resource `json:"resources"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&list)
+	if err != nil {
+		return "", &internal.StatusError{StatusCode: resp.StatusCode, Err: err}
+	}
+
+	if len(list.Resources) != 1 {
+		return "", fmt.Errorf("expected one resource at %s, found %d", path, len(list.Resources))
+	}
+	return list.Resources[0].GUID, nil
+}
+
+type v3Resource struct {
+	GUID string `json:"guid"`
+}
@@ -0,0 +1,153 @@
+package cf
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/cloudfoundry-community/go-cfclient"
+	"github.com/pivotal-cf/perm-test/cf/internal"
+)
+
+// Resource is the identity of an org, space or app, and the GUID of the org or space it belongs to
+type Resource struct {
+	GUID       string
+	Name       string
+	ParentGUID string
+}
+
+type v2Page struct {
+	NextURL   string `json:"next_url"`
+	Resources []struct {
+		Metadata struct {
+			GUID string `json:"guid"`
+		} `json:"metadata"`
+		Entity struct {
+			Name string `json:"name"`
+		} `json:"entity"`
+	} `json:"resources"`
+}
+
+type v3Relationship struct {
+	Data struct {
+		GUID string `json:"guid"`
+	} `json:"data"`
+}
+
+type v3Page struct {
+	Pagination struct {
+		Next *struct {
+			Href string `json:"href"`
+		} `json:"next"`
+	} `json:"pagination"`
+	Resources []struct {
+		GUID          string `json:"guid"`
+		Name          string `json:"name"`
+		Relationships struct {
+			Organization v3Relationship `json:"organization"`
+			Space        v3Relationship `json:"space"`
+		} `json:"relationships"`
+	} `json:"resources"`
+}
+
+// WalkV2 returns every resource from a V2 list endpoint, following next_url across all pages
+func WalkV2(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, path string) ([]Resource, error) {
+	var resources []Resource
+	for path != "" {
+		var page v2Page
+		err := getPage(ctx, logger, cfClient, path, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range page.Resources {
+			resources = append(resources, Resource{
+				GUID: r.Metadata.GUID,
+				Name: r.Entity.Name,
+			})
+		}
+		path = page.NextURL
+	}
+
+	return resources, nil
+}
+
+// WalkV3 returns every resource from a V3 list endpoint, following pagination.next across all pages
+//
+// ParentGUID is the GUID of the resource's space if it has one, or otherwise its org
+func WalkV3(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, path string) ([]Resource, error) {
+	var resources []Resource
+	for path != "" {
+		var page v3Page
+		err := getPage(ctx, logger, cfClient, path, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, r := range page.Resources {
+			parent := r.Relationships.Space.Data.GUID
+			if parent == "" {
+				parent = r.Relationships.Organization.Data.GUID
+			}
+
+			resources = append(resources, Resource{
+				GUID:       r.GUID,
+				Name:       r.Name,
+				ParentGUID: parent,
+			})
+		}
+
+		path = ""
+		if page.Pagination.Next != nil {
+			// next is an absolute URL, but requests are made relative to the API address
+			next, err := url.Parse(page.Pagination.Next.Href)
+			if err != nil {
+				return nil, err
+			}
+			path = next.RequestURI()
+		}
+	}
+
+	return resources, nil
+}
+
+// IsUserNotFound returns true if err is Cloud Controller reporting that the user in the request does not exist
+func IsUserNotFound(err error) bool {
+	if internal.HasErrorCode(err, internal.UserNotFound) {
+		return true
+	}
+	code, ok := internal.StatusCode(err)
+	return ok && code == http.StatusNotFound
+}
+
+func getPage(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, path string, page interface{}) error {
+	operation := func() error {
+		if err := wait(ctx, OperationList); err != nil {
+			return err
+		}
+
+		resp, err := cfClient.DoRequest(cfClient.NewRequest("GET", path))
+		if err != nil {
+			return internal.ResponseError(resp, err)
+		}
+		defer resp.Body.Close()
+
+		return json.NewDecoder(resp.Body).Decode(page)
+	}
+
+	err := retryNotify(ctx, operation, func(err error, step time.Duration) {
+		logger.Error("failed-to-get-page", err, lager.Data{
+			"path":         path,
+			"backoff.step": step.String(),
+		})
+	})
+	if err != nil {
+		logger.Error("finally-failed-to-get-page", err, lager.Data{
+			"path": path,
+		})
+	}
+	return err
+}
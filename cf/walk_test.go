@@ -0,0 +1,113 @@
+package cf_test
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/pivotal-cf/perm-test/cf"
+)
+
+var _ = Describe("Walk", func() {
+	var (
+		server *ghttp.Server
+
+		cfClient *cfclient.Client
+		logger   *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/info"),
+			ghttp.RespondWith(200, "{}", nil),
+		))
+
+		var err error
+		cfClient, err = cfclient.NewClient(&cfclient.Config{
+			ApiAddress: "http://" + server.Addr(),
+			Token:      "foobar",
+		})
+
+		Expect(err).NotTo(HaveOccurred())
+
+		logger = lagertest.NewTestLogger("walk")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("WalkV3", func() {
+		It("follows every page and records each resource's parent", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/spaces", "per_page=1"),
+					ghttp.RespondWith(200, `{
+						"pagination": {"next": {"href": "https://api.example.com/v3/spaces?page=2&per_page=1"}},
+						"resources": [{"guid": "space-0-guid", "name": "space-0", "relationships": {"organization": {"data": {"guid": "org-guid"}}}}]
+					}`, nil),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v3/spaces", "page=2&per_page=1"),
+					ghttp.RespondWith(200, `{
+						"pagination": {"next": null},
+						"resources": [{"guid": "space-1-guid", "name": "space-1", "relationships": {"organization": {"data": {"guid": "org-guid"}}}}]
+					}`, nil),
+				),
+			)
+
+			resources, err := WalkV3(context.Background(), logger, cfClient, "/v3/spaces?per_page=1")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(resources).To(Equal([]Resource{
+				{GUID: "space-0-guid", Name: "space-0", ParentGUID: "org-guid"},
+				{GUID: "space-1-guid", Name: "space-1", ParentGUID: "org-guid"},
+			}))
+		})
+	})
+
+	Describe("WalkV2", func() {
+		It("follows next_url across every page", func() {
+			server.AppendHandlers(
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/users/some-user-guid/organizations"),
+					ghttp.RespondWith(200, `{
+						"next_url": "/v2/users/some-user-guid/organizations?page=2",
+						"resources": [{"metadata": {"guid": "org-0-guid"}, "entity": {"name": "org-0"}}]
+					}`, nil),
+				),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/v2/users/some-user-guid/organizations", "page=2"),
+					ghttp.RespondWith(200, `{
+						"next_url": null,
+						"resources": [{"metadata": {"guid": "org-1-guid"}, "entity": {"name": "org-1"}}]
+					}`, nil),
+				),
+			)
+
+			resources, err := WalkV2(context.Background(), logger, cfClient, "/v2/users/some-user-guid/organizations")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(resources).To(Equal([]Resource{
+				{GUID: "org-0-guid", Name: "org-0"},
+				{GUID: "org-1-guid", Name: "org-1"},
+			}))
+		})
+
+		It("fails without retrying if the user does not exist", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("GET", "/v2/users/missing-user-guid/organizations"),
+				ghttp.RespondWith(404, `{"code": 20003, "description": "The user could not be found", "error_code": "CF-UserNotFound"}`, nil),
+			))
+
+			_, err := WalkV2(context.Background(), logger, cfClient, "/v2/users/missing-user-guid/organizations")
+			Expect(IsUserNotFound(err)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"code.cloudfoundry.org/lager"
+)
+
+type RunExperimentConfig struct {
+	LogLevel              string                `yaml:"log_level"`
+	ReportPath            string                `yaml:"report_path"`
+	MetricsAddress        string                `yaml:"metrics_address"`
+	CloudControllerConfig CloudControllerConfig `yaml:"cloud_controller"`
+	UserConfig            UserConfig            `yaml:"user"`
+	PersonaConfig         PersonaConfig         `yaml:"personas"`
+	ExperimentConfig      ExperimentConfig      `yaml:"experiment"`
+}
+
+// PersonaConfig, if a loaddata config is given, repeats every run as users
+// sampled from each bucket of the external environment seeded by that config
+//
+// The loaddata config must set a seed, so that the plan can be regenerated,
+// and provision users in UAA, so that they can log in.
+type PersonaConfig struct {
+	LoadDataConfigPath string `yaml:"loaddata_config"`
+	UsersPerBucket     int    `yaml:"users_per_bucket"`
+}
+
+// Enabled returns true if runs should be repeated as sampled users
+func (c PersonaConfig) Enabled() bool {
+	return c.LoadDataConfigPath != ""
+}
+
+type UserConfig struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+type ExperimentConfig struct {
+	WarmupRequests int              `yaml:"warmup_requests"`
+	Endpoints      []EndpointConfig `yaml:"endpoints"`
+}
+
+type EndpointConfig struct {
+	Path string      `yaml:"path"`
+	Runs []RunConfig `yaml:"runs"`
+}
+
+type RunConfig struct {
+	Requests    int `yaml:"requests"`
+	Concurrency int `yaml:"concurrency"`
+}
+
+// Redacted returns a copy of the config without the client secret or user's password, suitable for including in a report
+func (c RunExperimentConfig) Redacted() RunExperimentConfig {
+	if c.CloudControllerConfig.ClientSecret != "" {
+		c.CloudControllerConfig.ClientSecret = redacted
+	}
+	if c.UserConfig.Password != "" {
+		c.UserConfig.Password = redacted
+	}
+	return c
+}
+
+func (c *RunExperimentConfig) NewLogger(component string) lager.Logger {
+	return newLogger(component, c.LogLevel, os.Stdout)
+}
+
+func (c *RunExperimentConfig) Validate() error {
+	if c.UserConfig.Username == "" || c.UserConfig.Password == "" {
+		return errors.New("error in user: username and password must be provided")
+	}
+
+	if c.PersonaConfig.Enabled() && c.PersonaConfig.UsersPerBucket <= 0 {
+		return errors.New("error in personas: users_per_bucket must be positive")
+	}
+
+	if len(c.ExperimentConfig.Endpoints) == 0 {
+		return errors.New("error in experiment: at least one endpoint must be provided")
+	}
+
+	for _, e := range c.ExperimentConfig.Endpoints {
+		if e.Path == "" {
+			return errors.New("error in experiment: endpoint path must not be empty")
+		}
+
+		for _, r := range e.Runs {
+			if r.Requests <= 0 || r.Concurrency <= 0 {
+				return fmt.Errorf("error in experiment: requests and concurrency for %s must be positive", e.Path)
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,27 @@
+package cmd_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/pivotal-cf/perm-test/cmd"
+)
+
+var _ = Describe("RunExperimentConfig", func() {
+	Describe("Redacted", func() {
+		It("removes the client secret and the user's password", func() {
+			config := RunExperimentConfig{
+				CloudControllerConfig: CloudControllerConfig{ClientID: "some-client", ClientSecret: "some-secret"},
+				UserConfig:            UserConfig{Username: "some-user", Password: "some-password"},
+			}
+
+			redacted := config.Redacted()
+
+			Expect(redacted.CloudControllerConfig.ClientID).To(Equal("some-client"))
+			Expect(redacted.CloudControllerConfig.ClientSecret).NotTo(ContainSubstring("some-secret"))
+			Expect(redacted.UserConfig.Username).To(Equal("some-user"))
+			Expect(redacted.UserConfig.Password).NotTo(ContainSubstring("some-password"))
+			Expect(config.CloudControllerConfig.ClientSecret).To(Equal("some-secret"))
+		})
+	})
+})
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/pivotal-cf/perm-test/cf"
+	"github.com/pivotal-cf/perm-test/cmd"
+)
+
+// The functions below skip work already recorded in the manifest,
+// and record work in the manifest once it has completed.
+// Once ctx is done they return its error rather than starting any new work.
+
+func createOrg(ctx context.Context, logger lager.Logger, seeder cf.Seeder, manifest *cmd.Manifest, name string) (string, error) {
+	if guid, ok := manifest.Org(name); ok {
+		logger.Debug("skipping-org-in-manifest")
+		return guid, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	guid, err := seeder.CreateOrg(ctx, logger, name)
+	if err != nil {
+		return "", err
+	}
+
+	return guid, manifest.RecordOrg(name, guid)
+}
+
+func createSpace(ctx context.Context, logger lager.Logger, seeder cf.Seeder, manifest *cmd.Manifest, name string, orgGUID string) (string, error) {
+	if guid, ok := manifest.Space(name); ok {
+		logger.Debug("skipping-space-in-manifest")
+		return guid, nil
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	guid, err := seeder.CreateSpace(ctx, logger, name, orgGUID)
+	if err != nil {
+		return "", err
+	}
+
+	return guid, manifest.RecordSpace(name, guid)
+}
+
+func createApp(ctx context.Context, logger lager.Logger, seeder cf.Seeder, manifest *cmd.Manifest, name string, spaceGUID string) error {
+	if _, ok := manifest.App(name); ok {
+		logger.Debug("skipping-app-in-manifest")
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	guid, err := seeder.CreateApp(ctx, logger, name, spaceGUID)
+	if err != nil {
+		return err
+	}
+
+	return manifest.RecordApp(name, guid)
+}
+
+func createUser(ctx context.Context, logger lager.Logger, seeder cf.Seeder, manifest *cmd.Manifest, name string, guid string) error {
+	if _, ok := manifest.User(name); ok {
+		logger.Debug("skipping-user-in-manifest")
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := seeder.CreateUser(ctx, logger, guid)
+	if err != nil {
+		return err
+	}
+
+	return manifest.RecordUser(name, guid)
+}
+
+// assignOrgRole gives the user the org role
+func assignOrgRole(ctx context.Context, logger lager.Logger, seeder cf.Seeder, manifest *cmd.Manifest, role string, userGUID string, orgGUID string) error {
+	if manifest.HasRole(role, userGUID, orgGUID) {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var err error
+	switch role {
+	case cmd.RoleOrgUser:
+		err = seeder.AssociateUserWithOrg(ctx, logger, userGUID, orgGUID)
+	case cmd.RoleOrgManager:
+		err = seeder.AssociateOrgManager(ctx, logger, userGUID, orgGUID)
+	case cmd.RoleOrgBillingManager:
+		err = seeder.AssociateOrgBillingManager(ctx, logger, userGUID, orgGUID)
+	case cmd.RoleOrgAuditor:
+		err = seeder.AssociateOrgAuditor(ctx, logger, userGUID, orgGUID)
+	default:
+		err = fmt.Errorf("unknown org role %s", role)
+	}
+	if err != nil {
+		return err
+	}
+
+	return manifest.RecordRole(role, userGUID, orgGUID)
+}
+
+// assignSpaceRole gives the user the space role
+func assignSpaceRole(ctx context.Context, logger lager.Logger, seeder cf.Seeder, manifest *cmd.Manifest, role string, userGUID string, spaceGUID string) error {
+	if manifest.HasRole(role, userGUID, spaceGUID) {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var err error
+	switch role {
+	case cmd.RoleSpaceDeveloper:
+		err = seeder.MakeUserSpaceDeveloper(ctx, logger, userGUID, spaceGUID)
+	case cmd.RoleSpaceManager:
+		err = seeder.MakeUserSpaceManager(ctx, logger, userGUID, spaceGUID)
+	case cmd.RoleSpaceAuditor:
+		err = seeder.MakeUserSpaceAuditor(ctx, logger, userGUID, spaceGUID)
+	default:
+		err = fmt.Errorf("unknown space role %s", role)
+	}
+	if err != nil {
+		return err
+	}
+
+	return manifest.RecordRole(role, userGUID, spaceGUID)
+}
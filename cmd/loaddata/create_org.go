@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/pivotal-cf/perm-test/cf"
+	"github.com/pivotal-cf/perm-test/cmd"
+)
+
+// createAndPopulateOrg creates the org, its spaces and their apps
+//
+// If userGUID is not empty the user is made a user of the org and a developer of every space
+func createAndPopulateOrg(ctx context.Context, logger lager.Logger, seeder cf.Seeder, manifest *cmd.Manifest, org cmd.OrgPlan, userGUID string) error {
+	logger = logger.WithData(lager.Data{
+		"org.name": org.Name,
+	})
+
+	orgGUID, err := createOrg(ctx, logger, seeder, manifest, org.Name)
+	if err != nil {
+		return err
+	}
+
+	if userGUID != "" {
+		logger = logger.WithData(lager.Data{
+			"user.guid": userGUID,
+		})
+
+		err = assignOrgRole(ctx, logger, seeder, manifest, cmd.RoleOrgUser, userGUID, orgGUID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, space := range org.Spaces {
+		spaceLogger := logger.WithData(lager.Data{
+			"space.name": space.Name,
+		})
+
+		spaceGUID, err := createSpace(ctx, spaceLogger, seeder, manifest, space.Name, orgGUID)
+		if err != nil {
+			return err
+		}
+
+		if userGUID != "" {
+			err = assignSpaceRole(ctx, spaceLogger, seeder, manifest, cmd.RoleSpaceDeveloper, userGUID, spaceGUID)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, app := range space.Apps {
+			appLogger := spaceLogger.WithData(lager.Data{
+				"app.name": app,
+			})
+
+			err = createApp(ctx, appLogger, seeder, manifest, app, spaceGUID)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
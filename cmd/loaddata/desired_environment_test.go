@@ -0,0 +1,330 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/perm-test/cf/cffakes"
+	"github.com/pivotal-cf/perm-test/cmd"
+	"github.com/pivotal-cf/perm-test/report"
+	"golang.org/x/sync/semaphore"
+)
+
+var _ = Describe("Desired environments", func() {
+	var (
+		seeder   *cffakes.FakeSeeder
+		manifest *cmd.Manifest
+		plan     *cmd.Plan
+
+		logger   *lagertest.TestLogger
+		sem      *semaphore.Weighted
+		failures *FailureBudget
+	)
+
+	BeforeEach(func() {
+		seeder = new(cffakes.FakeSeeder)
+		seeder.CreateOrgStub = func(ctx context.Context, logger lager.Logger, name string) (string, error) {
+			return name + "-guid", nil
+		}
+		seeder.CreateSpaceStub = func(ctx context.Context, logger lager.Logger, name string, orgGUID string) (string, error) {
+			return name + "-guid", nil
+		}
+		seeder.CreateAppStub = func(ctx context.Context, logger lager.Logger, name string, spaceGUID string) (string, error) {
+			return name + "-guid", nil
+		}
+
+		manifest = cmd.NewManifest(nil)
+		plan = cmd.NewPlan(cmd.TestDataConfig{
+			SpacesPerOrgCount: 2,
+			AppsPerSpaceCount: 2,
+			TestEnvironmentConfig: cmd.TestEnvironmentConfig{
+				UserGUID: "test-user-guid",
+				OrgCount: 3,
+			},
+			ExternalEnvironmentConfig: cmd.ExternalEnvironmentConfig{
+				OrgCount:  5,
+				UserCount: 4,
+				UserOrgDistributions: []cmd.UserOrgDistribution{
+					{PercentUsers: 1, NumOrgs: 2},
+				},
+				UserSpaceDistributions: []cmd.UserSpaceDistribution{
+					{PercentUsers: 1, NumSpaces: 3},
+				},
+			},
+		}, 42)
+
+		logger = lagertest.NewTestLogger("loaddata")
+		sem = semaphore.NewWeighted(4)
+		failures = NewFailureBudget(0, func() {})
+	})
+
+	Describe("DesiredTestEnvironment", func() {
+		var e *DesiredTestEnvironment
+
+		BeforeEach(func() {
+			e = &DesiredTestEnvironment{
+				Plan:     plan.TestEnvironment,
+				Manifest: manifest,
+				Failures: failures,
+			}
+		})
+
+		It("creates every org, space and app and gives the test user access to all of them", func() {
+			Expect(e.Create(context.Background(), logger, sem, seeder)).To(Succeed())
+
+			Expect(seeder.CreateUserCallCount()).To(Equal(1))
+			_, _, userGUID := seeder.CreateUserArgsForCall(0)
+			Expect(userGUID).To(Equal("test-user-guid"))
+
+			Expect(seeder.CreateOrgCallCount()).To(Equal(3))
+			Expect(seeder.CreateSpaceCallCount()).To(Equal(6))
+			Expect(seeder.CreateAppCallCount()).To(Equal(12))
+			Expect(seeder.AssociateUserWithOrgCallCount()).To(Equal(3))
+			Expect(seeder.MakeUserSpaceDeveloperCallCount()).To(Equal(6))
+
+			Expect(manifest.HasRole(cmd.RoleSpaceDeveloper, "test-user-guid", "perm-test-space-1-in-org-2-guid")).To(BeTrue())
+
+			app := plan.TestEnvironment.Orgs[0].Spaces[0].Apps[0]
+			guid, ok := manifest.App(app)
+			Expect(ok).To(BeTrue())
+			Expect(guid).To(Equal(app + "-guid"))
+		})
+
+		It("skips work already recorded in the manifest", func() {
+			Expect(e.Create(context.Background(), logger, sem, new(cffakes.FakeSeeder))).To(Succeed())
+
+			Expect(e.Create(context.Background(), logger, sem, seeder)).To(Succeed())
+
+			Expect(seeder.Invocations()).To(BeEmpty())
+		})
+
+		It("stops starting work once the context is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			seeder.CreateOrgStub = func(ctx context.Context, logger lager.Logger, name string) (string, error) {
+				cancel()
+				return name + "-guid", nil
+			}
+
+			err := e.Create(ctx, logger, semaphore.NewWeighted(1), seeder)
+			Expect(err).To(Equal(context.Canceled))
+
+			Expect(seeder.CreateOrgCallCount()).To(Equal(1))
+			Expect(seeder.CreateSpaceCallCount()).To(BeZero())
+			Expect(manifest.Counts()).To(Equal(map[string]int{
+				cmd.ManifestEntryUser: 1,
+				cmd.ManifestEntryOrg:  1,
+			}))
+		})
+	})
+
+	Describe("DesiredExternalEnvironment", func() {
+		var e *DesiredExternalEnvironment
+
+		BeforeEach(func() {
+			e = &DesiredExternalEnvironment{
+				Plan:     plan.ExternalEnvironment,
+				Manifest: manifest,
+				Failures: failures,
+			}
+		})
+
+		It("creates every org, space, app and user and assigns the planned roles", func() {
+			Expect(e.Create(context.Background(), logger, sem, seeder)).To(Succeed())
+
+			Expect(seeder.CreateOrgCallCount()).To(Equal(5))
+			Expect(seeder.CreateSpaceCallCount()).To(Equal(10))
+			Expect(seeder.CreateAppCallCount()).To(Equal(20))
+			Expect(seeder.CreateUserCallCount()).To(Equal(4))
+			Expect(seeder.MakeUserSpaceDeveloperCallCount()).To(Equal(12))
+
+			var memberships int
+			for _, user := range plan.ExternalEnvironment.Users {
+				memberships += len(user.OrgMemberships())
+			}
+			Expect(seeder.AssociateUserWithOrgCallCount()).To(Equal(memberships))
+
+			for _, user := range plan.ExternalEnvironment.Users {
+				for _, a := range user.RoleAssignments() {
+					switch a.Role {
+					case cmd.RoleOrgUser:
+						Expect(manifest.HasRole(a.Role, user.GUID, a.OrgName+"-guid")).To(BeTrue())
+					case cmd.RoleSpaceDeveloper:
+						Expect(manifest.HasRole(a.Role, user.GUID, a.SpaceName+"-guid")).To(BeTrue())
+					}
+				}
+			}
+		})
+
+		It("records the orgs and users which fail and carries on with the rest", func() {
+			seeder.CreateOrgStub = func(ctx context.Context, logger lager.Logger, name string) (string, error) {
+				if name == plan.ExternalEnvironment.Orgs[0].Name {
+					return "", errors.New("quota exceeded")
+				}
+				return name + "-guid", nil
+			}
+			failures = NewFailureBudget(100, func() {})
+			e.Failures = failures
+
+			Expect(e.Create(context.Background(), logger, sem, seeder)).To(Succeed())
+
+			Expect(seeder.CreateOrgCallCount()).To(Equal(5))
+			Expect(seeder.CreateUserCallCount()).To(Equal(4))
+
+			recorded := failures.Failures()
+			Expect(recorded).NotTo(BeEmpty())
+			Expect(recorded[0]).To(Equal(report.Failure{
+				Type:  cmd.ManifestEntryOrg,
+				Name:  plan.ExternalEnvironment.Orgs[0].Name,
+				Error: "quota exceeded",
+			}))
+			for _, f := range recorded[1:] {
+				Expect(f.Type).To(Equal(cmd.ManifestEntryUser))
+				Expect(f.Error).To(ContainSubstring("has not been created"))
+			}
+			Expect(failures.Exceeded()).To(BeFalse())
+		})
+
+		It("stops starting work once the failure budget is exceeded", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			seeder.CreateOrgReturns("", errors.New("quota exceeded"))
+			failures = NewFailureBudget(0, cancel)
+			e.Failures = failures
+
+			err := e.Create(ctx, logger, semaphore.NewWeighted(1), seeder)
+			Expect(err).To(Equal(context.Canceled))
+
+			Expect(failures.Exceeded()).To(BeTrue())
+			Expect(failures.Failures()).To(HaveLen(1))
+			Expect(seeder.CreateOrgCallCount()).To(Equal(1))
+			Expect(seeder.CreateUserCallCount()).To(BeZero())
+		})
+
+		It("does not create users once the context is cancelled while creating orgs", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			seeder.CreateSpaceStub = func(ctx context.Context, logger lager.Logger, name string, orgGUID string) (string, error) {
+				cancel()
+				return name + "-guid", nil
+			}
+
+			err := e.Create(ctx, logger, sem, seeder)
+			Expect(err).To(Equal(context.Canceled))
+
+			Expect(seeder.CreateOrgCallCount()).To(BeNumerically("<", 5))
+			Expect(seeder.CreateUserCallCount()).To(BeZero())
+		})
+
+		It("does not record requests cancelled with the context as failures", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			seeder.CreateOrgStub = func(ctx context.Context, logger lager.Logger, name string) (string, error) {
+				cancel()
+				return "", &url.Error{Op: "Post", URL: "https://api.example.com/v2/organizations", Err: context.Canceled}
+			}
+			failures = NewFailureBudget(100, func() {})
+			e.Failures = failures
+
+			err := e.Create(ctx, logger, sem, seeder)
+			Expect(err).To(Equal(context.Canceled))
+
+			Expect(failures.Failures()).To(BeEmpty())
+		})
+
+		Context("when users are provisioned in UAA", func() {
+			var provisioner *fakeProvisioner
+
+			BeforeEach(func() {
+				provisioner = &fakeProvisioner{}
+				e.UAA = provisioner
+				e.UserPassword = "some-password"
+			})
+
+			It("creates each user in UAA and seeds it with its UAA GUID", func() {
+				Expect(e.Create(context.Background(), logger, sem, seeder)).To(Succeed())
+
+				Expect(provisioner.passwords).To(HaveLen(4))
+				for _, user := range plan.ExternalEnvironment.Users {
+					Expect(provisioner.passwords).To(HaveKeyWithValue(user.Name, "some-password"))
+
+					guid, ok := manifest.User(user.Name)
+					Expect(ok).To(BeTrue())
+					Expect(guid).To(Equal(user.Name + "-uaa-guid"))
+
+					for _, space := range user.Spaces {
+						Expect(manifest.HasRole(cmd.RoleSpaceDeveloper, guid, space.Name+"-guid")).To(BeTrue())
+					}
+				}
+
+				for i := 0; i < seeder.CreateUserCallCount(); i++ {
+					_, _, guid := seeder.CreateUserArgsForCall(i)
+					Expect(guid).To(HaveSuffix("-uaa-guid"))
+				}
+			})
+
+			It("does not provision users already recorded in the manifest", func() {
+				Expect(e.Create(context.Background(), logger, sem, seeder)).To(Succeed())
+				provisioner.passwords = nil
+
+				Expect(e.Create(context.Background(), logger, sem, seeder)).To(Succeed())
+
+				Expect(provisioner.passwords).To(BeEmpty())
+			})
+		})
+
+		Context("when the distributions have a role mix", func() {
+			BeforeEach(func() {
+				plan = cmd.NewPlan(cmd.TestDataConfig{
+					SpacesPerOrgCount: 2,
+					ExternalEnvironmentConfig: cmd.ExternalEnvironmentConfig{
+						OrgCount:  5,
+						UserCount: 4,
+						UserOrgDistributions: []cmd.UserOrgDistribution{
+							{PercentUsers: 1, NumOrgs: 2, RoleMix: []cmd.RoleWeight{{Role: cmd.RoleOrgAuditor, Percent: 1}}},
+						},
+						UserSpaceDistributions: []cmd.UserSpaceDistribution{
+							{PercentUsers: 1, NumSpaces: 3, RoleMix: []cmd.RoleWeight{{Role: cmd.RoleSpaceManager, Percent: 1}}},
+						},
+					},
+				}, 42)
+
+				e.Plan = plan.ExternalEnvironment
+			})
+
+			It("assigns the roles from the mix", func() {
+				Expect(e.Create(context.Background(), logger, sem, seeder)).To(Succeed())
+
+				Expect(seeder.AssociateOrgAuditorCallCount()).To(Equal(8))
+				Expect(seeder.MakeUserSpaceManagerCallCount()).To(Equal(12))
+				Expect(seeder.MakeUserSpaceDeveloperCallCount()).To(BeZero())
+
+				for _, user := range plan.ExternalEnvironment.Users {
+					for _, org := range user.Orgs {
+						Expect(manifest.HasRole(cmd.RoleOrgUser, user.GUID, org.Name+"-guid")).To(BeTrue())
+						Expect(manifest.HasRole(cmd.RoleOrgAuditor, user.GUID, org.Name+"-guid")).To(BeTrue())
+					}
+				}
+			})
+		})
+	})
+})
+
+type fakeProvisioner struct {
+	mu        sync.Mutex
+	passwords map[string]string
+}
+
+func (p *fakeProvisioner) CreateUser(ctx context.Context, logger lager.Logger, userName string, password string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.passwords == nil {
+		p.passwords = make(map[string]string)
+	}
+	p.passwords[userName] = password
+
+	return userName + "-uaa-guid", nil
+}
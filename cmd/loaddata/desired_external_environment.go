@@ -3,194 +3,223 @@ package main
 import (
 	"context"
 	"fmt"
-	"math/rand"
 	"sync"
-	"time"
 
 	"code.cloudfoundry.org/lager"
-	"github.com/cloudfoundry-community/go-cfclient"
 	"github.com/pivotal-cf/perm-test/cf"
 	"github.com/pivotal-cf/perm-test/cmd"
-	"github.com/satori/go.uuid"
-	"golang.org/x/sync/semaphore"
+	"github.com/pivotal-cf/perm-test/report"
 )
 
 type DesiredExternalEnvironment struct {
-	SpacesPerOrgCount      int
-	AppsPerSpaceCount      int
-	OrgCount               int
-	UserCount              int
-	UserOrgDistributions   []cmd.UserOrgDistribution
-	UserSpaceDistributions []cmd.UserSpaceDistribution
+	Plan     cmd.EnvironmentPlan
+	Manifest *cmd.Manifest
+
+	// Recorder, if not nil, records the duration of each phase
+	Recorder *report.Recorder
+
+	// Failures collects the orgs and users which could not be seeded
+	Failures *FailureBudget
+
+	// UAA, if not nil, creates each user with UserPassword before it is created by the seeder,
+	// so that the user can log in. The user is then known by its UAA GUID rather than the planned one.
+	UAA          UserProvisioner
+	UserPassword string
 }
 
-func (e *DesiredExternalEnvironment) Create(ctx context.Context, logger lager.Logger, sem *semaphore.Weighted, cfClient *cfclient.Client) {
-	// Create a bunch of orgs/spaces/apps
-	orgsBufferSize := e.OrgCount * 2
-	orgsCreatedChan := make(chan *cfclient.Org, orgsBufferSize)
+// UserProvisioner creates users which can log in, returning their GUIDs
+//
+// *uaa.Client satisfies this interface
+type UserProvisioner interface {
+	CreateUser(ctx context.Context, logger lager.Logger, userName string, password string) (string, error)
+}
 
-	spacesBufferSize := orgsBufferSize * e.SpacesPerOrgCount
-	spacesCreatedChan := make(chan *cfclient.Space, spacesBufferSize)
+// Create creates the orgs, spaces, apps and users in the plan and assigns the users their roles
+//
+// An org or user which fails is recorded in Failures and the rest carry on.
+// Once ctx is done no more work is started, and Create returns ctx's error
+// when the work already in flight has finished.
+func (e *DesiredExternalEnvironment) Create(ctx context.Context, logger lager.Logger, sem Semaphore, seeder cf.Seeder) error {
+	summary := e.Plan.Summary()
 
+	// Create a bunch of orgs/spaces/apps
 	logger.Debug("creating-orgs-spaces-and-apps", lager.Data{
-		"spaces-per-org-count": e.SpacesPerOrgCount,
-		"apps-per-space-count": e.AppsPerSpaceCount,
-		"org-count":            e.OrgCount,
+		"org-count":   summary.OrgCount,
+		"space-count": summary.SpaceCount,
+		"app-count":   summary.AppCount,
 	})
-	var err error
+	endPhase := e.Recorder.StartPhase("external-environment.create-orgs")
 	var wg sync.WaitGroup
-	for i := 0; i < e.OrgCount; i++ {
-		err = sem.Acquire(ctx, 1)
+	for _, org := range e.Plan.Orgs {
+		err := sem.Acquire(ctx, 1)
 		if err != nil {
-			logger.Error("failed-to-acquire-semaphore", err)
-			panic(err)
+			break
 		}
 
 		wg.Add(1)
-		go func(ctx context.Context, wg *sync.WaitGroup, sem *semaphore.Weighted, logger lager.Logger, i int) {
+		go func(ctx context.Context, wg *sync.WaitGroup, sem Semaphore, logger lager.Logger, org cmd.OrgPlan) {
 			defer wg.Done()
 			defer sem.Release(1)
 
-			orgName := fmt.Sprintf("perm-external-org-%d", i)
-			logger = logger.WithData(lager.Data{
-				"org.name": orgName,
-			})
-			org, err := cf.CreateOrgIfNotExists(logger, cfClient, orgName)
-			if err != nil {
-				panic(err)
+			err := createAndPopulateOrg(ctx, logger, seeder, e.Manifest, org, "")
+			if err != nil && ctx.Err() == nil {
+				e.Failures.Record(logger, cmd.ManifestEntryOrg, org.Name, err)
 			}
+		}(ctx, &wg, sem, logger, org)
+	}
+	wg.Wait()
+	endPhase()
 
-			orgsCreatedChan <- org
-
-			for j := 0; j < e.SpacesPerOrgCount; j++ {
-				spaceName := fmt.Sprintf("perm-external-space-%d-in-org-%d", j, i)
-				logger = logger.WithData(lager.Data{
-					"space.name": spaceName,
-				})
+	if err := ctx.Err(); err != nil {
+		logger.Info("interrupted-creating-orgs")
+		return err
+	}
 
-				space, err := cf.CreateSpaceIfNotExists(logger, cfClient, spaceName, org.Guid)
-				if err != nil {
-					panic(err)
-				}
+	// Create a bunch of users and assign each of them the roles in the plan
+	logger.Debug("creating-users-and-assigning-roles", lager.Data{
+		"user-count":             summary.UserCount,
+		"role-assignment-count":  summary.RoleAssignmentCount,
+		"org-associations-saved": summary.OrgAssociationsSaved,
+	})
+	endPhase = e.Recorder.StartPhase("external-environment.assign-roles")
+	for i, user := range e.Plan.Users {
+		err := sem.Acquire(ctx, 1)
+		if err != nil {
+			break
+		}
 
-				spacesCreatedChan <- space
+		logger.Debug("creating-user-and-assigning-roles", lager.Data{
+			"i":         i,
+			"numSpaces": len(user.Spaces),
+			"numOrgs":   len(user.Orgs),
+		})
+		wg.Add(1)
+		go func(ctx context.Context, wg *sync.WaitGroup, sem Semaphore, logger lager.Logger, user cmd.UserPlan) {
+			defer wg.Done()
+			defer sem.Release(1)
 
-				for k := 0; k < e.AppsPerSpaceCount; k++ {
-					appName := fmt.Sprintf("perm-external-app-%d-in-space-%d-in-org-%d", k, j, i)
-					logger = logger.WithData(lager.Data{
-						"app.name": appName,
-					})
+			logger = logger.WithData(lager.Data{
+				"user.name": user.Name,
+				"user.guid": user.GUID,
+			})
 
-					err = cf.CreateAppIfNotExists(logger, cfClient, appName, space.Guid)
-					if err != nil {
-						panic(err)
-					}
-				}
+			guid, err := e.createUser(ctx, logger, seeder, user)
+			if err == nil {
+				user.GUID = guid
+				err = assignRoles(ctx, logger, seeder, e.Manifest, user)
 			}
-
-		}(ctx, &wg, sem, logger, i)
+			if err != nil && ctx.Err() == nil {
+				e.Failures.Record(logger, cmd.ManifestEntryUser, user.Name, err)
+			}
+		}(ctx, &wg, sem, logger, user)
 	}
 	wg.Wait()
-	close(orgsCreatedChan)
-	close(spacesCreatedChan)
+	endPhase()
 
-	var orgsCreated []*cfclient.Org
-	for org := range orgsCreatedChan {
-		orgsCreated = append(orgsCreated, org)
+	if err := ctx.Err(); err != nil {
+		logger.Info("interrupted-assigning-roles")
+		return err
 	}
 
-	var spacesCreated []*cfclient.Space
-	for space := range spacesCreatedChan {
-		spacesCreated = append(spacesCreated, space)
+	return nil
+}
+
+// createUser creates the user, in UAA first if there is a provisioner, and returns its GUID
+func (e *DesiredExternalEnvironment) createUser(ctx context.Context, logger lager.Logger, seeder cf.Seeder, user cmd.UserPlan) (string, error) {
+	if guid, ok := e.Manifest.User(user.Name); ok {
+		logger.Debug("skipping-user-in-manifest")
+		return guid, nil
 	}
 
-	logger.Debug("creating-users-and-assigning-roles", lager.Data{
-		"spaces-per-org-count":    e.SpacesPerOrgCount,
-		"apps-per-space-count":    e.AppsPerSpaceCount,
-		"org-count":               e.OrgCount,
-		"user-count":              e.UserCount,
-		"user-org-distribution":   e.UserOrgDistributions,
-		"user-space-distribution": e.UserSpaceDistributions,
+	guid := user.GUID
+	if e.UAA != nil {
+		var err error
+		guid, err = e.UAA.CreateUser(ctx, logger, user.Name, e.UserPassword)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return guid, createUser(ctx, logger, seeder, e.Manifest, user.Name, guid)
+}
+
+// assignRoles makes the user a member of each of its orgs and the orgs containing its spaces,
+// once each, then gives it its planned role in each of its spaces and orgs
+//
+// The orgs and spaces must already have been created and recorded in the manifest
+func assignRoles(ctx context.Context, logger lager.Logger, seeder cf.Seeder, manifest *cmd.Manifest, user cmd.UserPlan) error {
+	memberships := user.OrgMemberships()
+	logger.Debug("associating-user-with-orgs", lager.Data{
+		"org.count": len(memberships),
+		"saved":     len(user.Spaces) + len(user.Orgs) - len(memberships),
 	})
-	// Create a bunch of users
-	// For every user
-	//  Calculate the number of orgs it should see
-	//    Randomly assign an org role for that many orgs
-	//  Calculate the number of spaces it should see
-	//    Randomly assign a space role for that many spaces
-	r := rand.New(rand.NewSource(time.Now().UTC().UnixNano()))
-
-	for i := 0; i < e.UserCount; i++ {
-		err = sem.Acquire(ctx, 1)
+	for _, orgName := range memberships {
+		orgLogger := logger.WithData(lager.Data{
+			"org.name": orgName,
+		})
+
+		orgGUID, ok := manifest.Org(orgName)
+		if !ok {
+			return fmt.Errorf("org %s has not been created", orgName)
+		}
+
+		orgLogger.Debug("associating-user-with-org")
+		err := assignOrgRole(ctx, orgLogger, seeder, manifest, cmd.RoleOrgUser, user.GUID, orgGUID)
 		if err != nil {
-			logger.Error("failed-to-acquire-semaphore", err)
-			panic(err)
+			return err
 		}
+	}
 
-		numOrgAssignments := cmd.ChooseNumOrgAssignments(r, e.UserOrgDistributions)
-		orgs := cmd.RandomlyChooseOrgs(r, orgsCreated, numOrgAssignments)
+	logger.Debug("assigning-space-roles", lager.Data{
+		"space.count": len(user.Spaces),
+	})
+	for _, space := range user.Spaces {
+		spaceLogger := logger.WithData(lager.Data{
+			"org.name":   space.OrgName,
+			"space.name": space.Name,
+		})
 
-		numSpaceAssignments := cmd.ChooseNumSpaceAssignments(r, e.UserSpaceDistributions)
-		spaces := cmd.RandomlyChooseSpaces(r, spacesCreated, numSpaceAssignments)
+		spaceGUID, ok := manifest.Space(space.Name)
+		if !ok {
+			return fmt.Errorf("space %s has not been created", space.Name)
+		}
 
-		logger.Debug("creating-user-and-assigning-roles", lager.Data{
-			"i": i,
-			"numSpaceAssignments": numSpaceAssignments,
-			"numSpaces":           len(spaces),
-			"numOrgAssignments":   numOrgAssignments,
-			"numOrgs":             len(orgs),
+		role := user.SpaceRole(space.Name)
+		spaceLogger.Debug("assigning-space-role", lager.Data{
+			"role": role,
 		})
-		wg.Add(1)
-		go func(ctx context.Context, wg *sync.WaitGroup, sem *semaphore.Weighted, logger lager.Logger, i int, r *rand.Rand, orgs []*cfclient.Org, spaces []*cfclient.Space) {
-			defer wg.Done()
-			defer sem.Release(1)
+		err := assignSpaceRole(ctx, spaceLogger, seeder, manifest, role, user.GUID, spaceGUID)
+		if err != nil {
+			return err
+		}
+	}
 
-			userUUID := uuid.NewV4()
+	logger.Debug("assigning-org-roles", lager.Data{
+		"org.count": len(user.Orgs),
+	})
+	for _, org := range user.Orgs {
+		role := user.OrgRole(org.Name)
+		if role == cmd.RoleOrgUser {
+			continue
+		}
 
-			logger = logger.WithData(lager.Data{
-				"user.guid": userUUID.String(),
-			})
-			user, err := cf.CreateUser(logger, cfClient, userUUID.String())
-			if err != nil {
-				panic(err)
-			}
+		orgLogger := logger.WithData(lager.Data{
+			"org.name": org.Name,
+		})
 
-			logger.Debug("assigning-space-roles", lager.Data{
-				"space.count": len(spaces),
-			})
-			for _, space := range spaces {
-				spaceLogger := logger.WithData(lager.Data{
-					"org.guid":   space.OrganizationGuid,
-					"space.name": space.Name,
-				})
-
-				spaceLogger.Debug("associating-user-with-org-for-space")
-				err = cf.AssociateUserWithOrg(logger, cfClient, user.Guid, space.OrganizationGuid)
-				if err != nil {
-					panic(err)
-				}
-
-				spaceLogger.Debug("making-user-space-developer")
-				err = cf.MakeUserSpaceDeveloper(logger, cfClient, user.Guid, space.Guid)
-				if err != nil {
-					panic(err)
-				}
-			}
+		orgGUID, ok := manifest.Org(org.Name)
+		if !ok {
+			return fmt.Errorf("org %s has not been created", org.Name)
+		}
 
-			logger.Debug("assigning-org-roles", lager.Data{
-				"org.count": len(orgs),
-			})
-			for _, org := range orgs {
-				orgLogger := logger.WithData(lager.Data{
-					"org.name": org.Name,
-				})
-				orgLogger.Debug("associating-user-with-org")
-
-				err = cf.AssociateUserWithOrg(logger, cfClient, user.Guid, org.Guid)
-				if err != nil {
-					panic(err)
-				}
-			}
-		}(ctx, &wg, sem, logger, i, r, orgs, spaces)
+		orgLogger.Debug("assigning-org-role", lager.Data{
+			"role": role,
+		})
+		err := assignOrgRole(ctx, orgLogger, seeder, manifest, role, user.GUID, orgGUID)
+		if err != nil {
+			return err
+		}
 	}
+
+	return nil
 }
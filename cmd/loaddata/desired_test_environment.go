@@ -2,97 +2,62 @@ package main
 
 import (
 	"context"
-	"fmt"
 	"sync"
 
 	"code.cloudfoundry.org/lager"
-	"github.com/cloudfoundry-community/go-cfclient"
 	"github.com/pivotal-cf/perm-test/cf"
-	"golang.org/x/sync/semaphore"
+	"github.com/pivotal-cf/perm-test/cmd"
 )
 
 type DesiredTestEnvironment struct {
-	UserGUID          string
-	OrgCount          int
-	SpacesPerOrgCount int
-	AppsPerSpaceCount int
+	Plan     cmd.EnvironmentPlan
+	Manifest *cmd.Manifest
+
+	// Failures collects the orgs and users which could not be seeded
+	Failures *FailureBudget
 }
 
-func (e *DesiredTestEnvironment) Create(ctx context.Context, logger lager.Logger, sem *semaphore.Weighted, cfClient *cfclient.Client) {
-	user, err := cf.CreateUser(logger, cfClient, e.UserGUID)
+// Create creates the user in the plan and its orgs, spaces and apps
+//
+// An org which fails is recorded in Failures and the rest carry on,
+// but nothing is created if the user fails.
+// Once ctx is done no more work is started, and Create returns ctx's error
+// when the work already in flight has finished.
+func (e *DesiredTestEnvironment) Create(ctx context.Context, logger lager.Logger, sem Semaphore, seeder cf.Seeder) error {
+	user := e.Plan.Users[0]
+
+	err := createUser(ctx, logger, seeder, e.Manifest, user.Name, user.GUID)
 	if err != nil {
-		panic(err)
+		if ctx.Err() == nil {
+			e.Failures.Record(logger, cmd.ManifestEntryUser, user.Name, err)
+		}
+		return ctx.Err()
 	}
 
 	var wg sync.WaitGroup
-	for i := 0; i < e.OrgCount; i++ {
+	for _, org := range e.Plan.Orgs {
 		err = sem.Acquire(ctx, 1)
 		if err != nil {
-			logger.Error("failed-to-acquire-semaphore", err)
-			panic(err)
+			break
 		}
 
 		wg.Add(1)
-		go func(ctx context.Context, wg *sync.WaitGroup, logger lager.Logger, i int) {
+		go func(ctx context.Context, wg *sync.WaitGroup, logger lager.Logger, org cmd.OrgPlan) {
 			defer wg.Done()
 			defer sem.Release(1)
 
-			err = createAndPopulateOrgInTestEnvironment(logger, cfClient, i, user.Guid, e.SpacesPerOrgCount, e.AppsPerSpaceCount)
-			if err != nil {
-				panic(err)
+			err := createAndPopulateOrg(ctx, logger, seeder, e.Manifest, org, user.GUID)
+			if err != nil && ctx.Err() == nil {
+				e.Failures.Record(logger, cmd.ManifestEntryOrg, org.Name, err)
 			}
-		}(ctx, &wg, logger, i)
+		}(ctx, &wg, logger, org)
 	}
 	wg.Wait()
-}
-
-func createAndPopulateOrgInTestEnvironment(logger lager.Logger, cfClient *cfclient.Client, i int, userGUID string, spacesPerOrgCount int, appsPerSpaceCount int) error {
-	orgName := fmt.Sprintf("perm-test-org-%d", i)
-	logger = logger.WithData(lager.Data{
-		"org.name": orgName,
-	})
 
-	org, err := cf.CreateOrgIfNotExists(logger, cfClient, orgName)
-	if err != nil {
-		return err
-	}
-
-	logger = logger.WithData(lager.Data{
-		"user.guid": userGUID,
-	})
-
-	err = cf.AssociateUserWithOrg(logger, cfClient, userGUID, org.Guid)
-	if err != nil {
+	if err := ctx.Err(); err != nil {
+		logger.Info("interrupted")
 		return err
 	}
 
-	for j := 0; j < spacesPerOrgCount; j++ {
-		spaceName := fmt.Sprintf("perm-test-space-%d-in-org-%d", j, i)
-		logger = logger.WithData(lager.Data{
-			"space.name": spaceName,
-		})
-
-		space, err := cf.CreateSpaceIfNotExists(logger, cfClient, spaceName, org.Guid)
-		if err != nil {
-			return err
-		}
-
-		err = cf.MakeUserSpaceDeveloper(logger, cfClient, userGUID, space.Guid)
-		if err != nil {
-			return err
-		}
-
-		for k := 0; k < appsPerSpaceCount; k++ {
-			appName := fmt.Sprintf("perm-test-app-%d-in-space-%d-in-org-%d", k, j, i)
-			logger = logger.WithData(lager.Data{
-				"app.name": appName,
-			})
-
-			err = cf.CreateAppIfNotExists(logger, cfClient, appName, space.Guid)
-			if err != nil {
-				return err
-			}
-		}
-	}
 	return nil
 }
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/pivotal-cf/perm-test/report"
+)
+
+// FailureBudget collects the errors returned by seeding workers,
+// stopping the run once more of them have failed than it allows
+type FailureBudget struct {
+	mu       sync.Mutex
+	allowed  int
+	failures []report.Failure
+	exceeded bool
+
+	// stop cancels the context of the workers
+	stop func()
+}
+
+func NewFailureBudget(allowed int, stop func()) *FailureBudget {
+	return &FailureBudget{
+		allowed: allowed,
+		stop:    stop,
+	}
+}
+
+// Record records that the named resource, of a manifest entry type, could not be seeded
+//
+// If that exceeds the budget, no further work is started.
+func (b *FailureBudget) Record(logger lager.Logger, resourceType string, name string, err error) {
+	logger.Error("failed-to-seed-"+resourceType, err, lager.Data{
+		"name": name,
+	})
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = append(b.failures, report.Failure{
+		Type:  resourceType,
+		Name:  name,
+		Error: err.Error(),
+	})
+
+	if len(b.failures) > b.allowed && !b.exceeded {
+		b.exceeded = true
+		logger.Info("failure-budget-exceeded", lager.Data{
+			"failures": len(b.failures),
+			"allowed":  b.allowed,
+		})
+		b.stop()
+	}
+}
+
+// Failures returns every failure recorded, in the order they were recorded
+func (b *FailureBudget) Failures() []report.Failure {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	failures := make([]report.Failure, len(b.failures))
+	copy(failures, b.failures)
+	return failures
+}
+
+// Exceeded returns true if more failures have been recorded than the budget allows
+func (b *FailureBudget) Exceeded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.exceeded
+}
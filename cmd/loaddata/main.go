@@ -11,26 +11,57 @@ import (
 	"github.com/cloudfoundry-community/go-cfclient"
 	"github.com/pivotal-cf/perm-test/cf"
 	"github.com/pivotal-cf/perm-test/cmd"
+	"github.com/pivotal-cf/perm-test/limiter"
+	"github.com/pivotal-cf/perm-test/metrics"
+	"github.com/pivotal-cf/perm-test/perm"
+	"github.com/pivotal-cf/perm-test/report"
+	"github.com/pivotal-cf/perm-test/uaa"
 	"gopkg.in/yaml.v2"
 
 	"net/http"
 	"sync"
+)
 
-	"golang.org/x/sync/semaphore"
+// Exit codes of a seeding run which did not seed everything
+const (
+	ExitCodeFailureBudgetExceeded = 1
+	ExitCodeFailuresWithinBudget  = 3
 )
 
+// Defaults for settings which are not in the config
 const (
-	NumParallelWorkers     = 12
-	CloudControllerTimeout = 3 * time.Second
+	DefaultConcurrency            = 12
+	DefaultCloudControllerTimeout = 3 * time.Second
+	DefaultPermTimeout            = 3 * time.Second
+	DefaultLatencyThreshold       = time.Second
 )
 
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: loaddata <path/to/config.yml>")
-		os.Exit(2)
+		usage()
 	}
 
-	configPath := os.Args[1]
+	switch os.Args[1] {
+	case "plan":
+		plan(os.Args[2:])
+	case "teardown":
+		teardown(os.Args[2:])
+	case "verify":
+		verify(os.Args[2:])
+	default:
+		seedData(os.Args[1])
+	}
+}
+
+func usage() {
+	fmt.Println("Usage: loaddata <path/to/config.yml>")
+	fmt.Println("       loaddata plan [-format json|csv] <path/to/config.yml>")
+	fmt.Println("       loaddata teardown [-manifest <path/to/manifest.jsonl>] [-dry-run] <path/to/config.yml>")
+	fmt.Println("       loaddata verify <path/to/config.yml> > report.json")
+	os.Exit(2)
+}
+
+func loadConfig(configPath string) cmd.LoadDataConfig {
 	contents, err := ioutil.ReadFile(configPath)
 	if err != nil {
 		fmt.Printf("Error reading config file: %s\n", err.Error())
@@ -44,81 +75,420 @@ func main() {
 		panic(err)
 	}
 
+	return config
+}
+
+// concurrencyFor returns the configured number of workers, or DefaultConcurrency if there is none
+func concurrencyFor(config cmd.LoadDataConfig) int64 {
+	if config.Concurrency > 0 {
+		return int64(config.Concurrency)
+	}
+
+	return DefaultConcurrency
+}
+
+// newLimiter returns the adaptive limiter on the number of seeding workers,
+// filling in the defaults for anything missing from the config
+func newLimiter(config cmd.LoadDataConfig) *limiter.AIMD {
+	initial := int(concurrencyFor(config))
+
+	a := config.AdaptiveConcurrencyConfig
+	if a.Min == 0 {
+		a.Min = 1
+	}
+	if a.Max == 0 {
+		a.Max = 4 * initial
+	}
+	if a.LatencyThreshold == 0 {
+		a.LatencyThreshold = DefaultLatencyThreshold
+	}
+
+	if initial < a.Min {
+		initial = a.Min
+	}
+	if initial > a.Max {
+		initial = a.Max
+	}
+
+	return limiter.NewAIMD(initial, a.Min, a.Max, a.LatencyThreshold)
+}
+
+// setRateLimit limits the rate of requests made to Cloud Controller by package cf, if the config has a limit
+func setRateLimit(logger lager.Logger, config cmd.RateLimitConfig) {
+	if !config.Enabled() {
+		return
+	}
+
+	logger.Info("limiting-request-rate", lager.Data{
+		"requests-per-second": config.RequestsPerSecond,
+		"burst":               config.Burst,
+		"operations":          config.Operations,
+	})
+
+	cf.SetRateLimiter(limiter.NewRate(config.RequestsPerSecond, config.Burst, config.Operations))
+}
+
+// setRetryPolicy makes package cf retry failed requests as configured
+func setRetryPolicy(logger lager.Logger, config cmd.RetryConfig) {
+	policy := config.Policy()
+
+	logger.Debug("setting-retry-policy", lager.Data{
+		"max-elapsed-time":       policy.MaxElapsedTime.String(),
+		"initial-interval":       policy.InitialInterval.String(),
+		"max-interval":           policy.MaxInterval.String(),
+		"multiplier":             policy.Multiplier,
+		"jitter":                 policy.RandomizationFactor,
+		"retryable-status-codes": policy.RetryableStatusCodes,
+		"retryable-error-codes":  policy.RetryableErrorCodes,
+	})
+
+	cf.SetRetryPolicy(policy)
+}
+
+// newAppShape returns the shape of the config, finding the shared domain and service plan it refers to
+func newAppShape(logger lager.Logger, cfClient *cfclient.Client, config cmd.AppShapeConfig) cf.AppShape {
+	logger = logger.Session("app-shape")
+
+	shape := cf.AppShape{
+		Routes:           config.RoutesPerApp,
+		ServiceInstances: config.ServiceInstancesPerApp,
+		EnvVars:          config.EnvVarsPerApp,
+		Instances:        config.Instances,
+		MemoryInMB:       config.MemoryInMB,
+	}
+
+	var err error
+	if shape.Routes > 0 {
+		shape.DomainGUID, err = cf.FindDomain(context.Background(), logger, cfClient, config.SharedDomain)
+		if err != nil {
+			logger.Error("failed-to-find-shared-domain", err)
+			panic(err)
+		}
+	}
+
+	if shape.ServiceInstances > 0 {
+		shape.ServicePlanGUID, err = cf.FindServicePlan(context.Background(), logger, cfClient, config.ServiceOffering, config.ServicePlan)
+		if err != nil {
+			logger.Error("failed-to-find-service-plan", err)
+			panic(err)
+		}
+	}
+
+	logger.Info("shaping-apps", lager.Data{
+		"routes":            shape.Routes,
+		"service-instances": shape.ServiceInstances,
+		"env-vars":          shape.EnvVars,
+		"instances":         shape.Instances,
+		"memory-in-mb":      shape.MemoryInMB,
+	})
+
+	return shape
+}
+
+// seedFor returns the configured seed, or one chosen from the current time if there is none
+func seedFor(config cmd.LoadDataConfig) int64 {
+	if config.TestDataConfig.Seed != 0 {
+		return config.TestDataConfig.Seed
+	}
+
+	return time.Now().UTC().UnixNano()
+}
+
+func seedData(configPath string) {
+	config := loadConfig(configPath)
+
 	logger := config.NewLogger("perm-loaddata")
-	err = config.Validate()
+	err := config.Validate()
 	if err != nil {
 		logger.Error("failed-to-validate-config", err)
 		panic(err)
 	}
 
-	logger.Info("starting")
+	startedAt := time.Now()
+	recorder := report.NewRecorder()
 
-	httpClient := &http.Client{
-		Timeout: CloudControllerTimeout,
+	registry := metrics.NewRegistry()
+	if config.MetricsAddress != "" {
+		go serveMetrics(logger, config.MetricsAddress, registry)
 	}
 
-	cfClientConfig := &cfclient.Config{
-		ApiAddress:        config.CloudControllerConfig.URL,
-		Username:          config.CloudControllerConfig.ClientID,
-		Password:          config.CloudControllerConfig.ClientSecret,
-		SkipSslValidation: true,
-		HttpClient:        httpClient,
+	seed := seedFor(config)
+	plan := cmd.NewPlan(config.TestDataConfig, seed)
+
+	logger.Info("starting", lager.Data{
+		"seed": seed,
+	})
+
+	defer logger.Info("finished")
+
+	aimd := newLimiter(config)
+	setRateLimit(logger, config.RateLimitConfig)
+	setRetryPolicy(logger, config.RetryConfig)
+
+	var (
+		seeder   cf.Seeder
+		cfClient *cfclient.Client
+	)
+	switch config.Backend {
+	case cmd.BackendPerm:
+		seeder = newPermSeeder(logger, config.PermConfig, config.RetryConfig)
+	default:
+		httpClient := newHTTPClient(logger, config.CloudControllerConfig)
+		httpClient.Transport = limiter.NewTransport(aimd, httpClient.Transport)
+		httpClient.Transport = metrics.NewTransport(registry, "perm_test_cc_request_duration_seconds", httpClient.Transport)
+
+		cfClient = newCFClient(logger, config.CloudControllerConfig, httpClient)
+		ccSeeder := cf.NewCloudControllerSeeder(cfClient)
+		ccSeeder.V3 = config.CloudControllerConfig.APIVersion == cmd.APIVersionV3
+		ccSeeder.V3Roles = config.CloudControllerConfig.V3Roles
+		if !config.TestDataConfig.AppShapeConfig.IsZero() {
+			ccSeeder.AppShape = newAppShape(logger, cfClient, config.TestDataConfig.AppShapeConfig)
+		}
+		seeder = ccSeeder
 	}
-	cfClient, err := cfclient.NewClient(cfClientConfig)
-	if err != nil {
-		logger.Error("failed-to-make-cf-client", err)
-		panic(err)
+	seeder = report.NewSeeder(seeder, recorder, metrics.NewSeederMetrics(registry))
+
+	var uaaClient *uaa.Client
+	if config.UAAConfig.Enabled() {
+		uaaClient = newUAAClient(logger, config, cfClient)
 	}
 
-	defer logger.Info("finished")
+	manifest := cmd.NewManifest(nil)
+	if config.CheckpointPath != "" {
+		manifest, err = cmd.OpenManifest(config.CheckpointPath)
+		if err != nil {
+			logger.Error("failed-to-open-checkpoint", err)
+			panic(err)
+		}
+
+		logger.Info("resuming-from-checkpoint", lager.Data{
+			"path":    config.CheckpointPath,
+			"entries": len(manifest.Entries()),
+		})
+	}
+	defer manifest.Close()
+
+	ctx, stop := cmd.WithInterrupt(context.Background(), logger)
+	defer stop()
+
+	// seeding also stops once more orgs and users have failed than the budget allows
+	seedCtx, stopSeeding := context.WithCancel(ctx)
+	defer stopSeeding()
 
-	ctx := context.Background()
-	sem := semaphore.NewWeighted(NumParallelWorkers)
+	planned := len(plan.TestEnvironment.Orgs) + len(plan.TestEnvironment.Users) +
+		len(plan.ExternalEnvironment.Orgs) + len(plan.ExternalEnvironment.Users)
+	failures := NewFailureBudget(config.FailureBudgetConfig.Allowed(planned), stopSeeding)
+
+	sem := newInstrumentedSemaphore(aimd, registry.NewGauge("perm_test_seeder_workers_in_flight", "Seeding workers holding the semaphore."))
 
 	var wg sync.WaitGroup
 	wg.Add(2)
 	go func() {
 		defer wg.Done()
+		defer recorder.StartPhase("test-environment")()
 
 		e := &DesiredTestEnvironment{
-			UserGUID:          config.TestDataConfig.TestEnvironmentConfig.UserGUID,
-			OrgCount:          config.TestDataConfig.TestEnvironmentConfig.OrgCount,
-			SpacesPerOrgCount: config.TestDataConfig.SpacesPerOrgCount,
-			AppsPerSpaceCount: config.TestDataConfig.AppsPerSpaceCount,
+			Plan:     plan.TestEnvironment,
+			Manifest: manifest,
+			Failures: failures,
 		}
 
-		e.Create(ctx, logger.Session("create-test-environment"), sem, cfClient)
+		e.Create(seedCtx, logger.Session("create-test-environment"), sem, seeder)
 	}()
 
 	go func() {
 		defer wg.Done()
+		defer recorder.StartPhase("external-environment")()
 
 		e := &DesiredExternalEnvironment{
-			UserCount:              config.TestDataConfig.ExternalEnvironmentConfig.UserCount,
-			OrgCount:               config.TestDataConfig.ExternalEnvironmentConfig.OrgCount,
-			SpacesPerOrgCount:      config.TestDataConfig.SpacesPerOrgCount,
-			AppsPerSpaceCount:      config.TestDataConfig.AppsPerSpaceCount,
-			UserOrgDistributions:   config.TestDataConfig.ExternalEnvironmentConfig.UserOrgDistributions,
-			UserSpaceDistributions: config.TestDataConfig.ExternalEnvironmentConfig.UserSpaceDistributions,
+			Plan:     plan.ExternalEnvironment,
+			Manifest: manifest,
+			Recorder: recorder,
+			Failures: failures,
+		}
+		if uaaClient != nil {
+			e.UAA = uaaClient
+			e.UserPassword = config.UAAConfig.UserPassword
 		}
 
-		e.Create(ctx, logger.Session("create-external-environment"), sem, cfClient)
+		e.Create(seedCtx, logger.Session("create-external-environment"), sem, seeder)
 	}()
 
-	go func() {
-		for range time.NewTicker(10 * time.Second).C {
-			orgCount, _ := cf.OrgCount(logger, cfClient)
-			spaceCount, _ := cf.SpaceCount(logger, cfClient)
-			userCount, _ := cf.UserCount(logger, cfClient)
-
-			logger.Info("progress", lager.Data{
-				"org-count":   orgCount,
-				"space-count": spaceCount,
-				"user-count":  userCount,
-			})
-		}
-	}()
+	progressCtx, stopProgress := context.WithCancel(ctx)
+	go reportProgress(progressCtx, logger, cfClient, aimd, progressGauges{
+		resources:        registry.NewGauge("perm_test_cc_resources", "Resources in Cloud Controller, by type.", "type"),
+		concurrencyLimit: registry.NewGauge("perm_test_seeder_concurrency_limit", "Seeding workers allowed by the adaptive limiter."),
+	})
 
 	wg.Wait()
+	stopProgress()
+
+	if config.ReportPath != "" {
+		doc := report.NewDocument("loaddata", config.Redacted(), startedAt, recorder)
+		doc.Failures = failures.Failures()
+
+		err = doc.WriteFile(config.ReportPath)
+		if err != nil {
+			logger.Error("failed-to-write-report", err)
+			panic(err)
+		}
+	}
+
+	exitCode := 0
+	switch {
+	case ctx.Err() != nil:
+		exitCode = cmd.InterruptExitCode
+	case failures.Exceeded():
+		exitCode = ExitCodeFailureBudgetExceeded
+	case len(failures.Failures()) > 0:
+		exitCode = ExitCodeFailuresWithinBudget
+	default:
+		return
+	}
+
+	logger.Info("finished-incomplete", lager.Data{
+		"completed":   manifest.Counts(),
+		"failures":    len(failures.Failures()),
+		"interrupted": ctx.Err() != nil,
+		"exit-code":   exitCode,
+	})
+
+	manifest.Close()
+	os.Exit(exitCode)
+}
+
+// newHTTPClient returns a client for Cloud Controller and UAA with the configured timeout and TLS settings
+func newHTTPClient(logger lager.Logger, config cmd.CloudControllerConfig) *http.Client {
+	tlsConfig, err := config.NewTLSConfig()
+	if err != nil {
+		logger.Error("failed-to-load-cloud-controller-ca-cert", err)
+		panic(err)
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = DefaultCloudControllerTimeout
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+}
+
+// newCFClient returns a client for Cloud Controller whose requests are made through httpClient
+func newCFClient(logger lager.Logger, config cmd.CloudControllerConfig, httpClient *http.Client) *cfclient.Client {
+	cfClientConfig := &cfclient.Config{
+		ApiAddress:        config.URL,
+		Username:          config.ClientID,
+		Password:          config.ClientSecret,
+		SkipSslValidation: config.SkipSSLValidation(),
+		HttpClient:        httpClient,
+	}
+	cfClient, err := cfclient.NewClient(cfClientConfig)
+	if err != nil {
+		logger.Error("failed-to-make-cf-client", err)
+		panic(err)
+	}
+
+	return cfClient
+}
 
+// newUAAClient returns a client for the configured UAA, or the UAA advertised by Cloud Controller if no URL is configured
+//
+// UAA is connected to with the same timeout and TLS settings as Cloud Controller
+func newUAAClient(logger lager.Logger, config cmd.LoadDataConfig, cfClient *cfclient.Client) *uaa.Client {
+	url := config.UAAConfig.URL
+	if url == "" {
+		url = cfClient.Endpoint.TokenEndpoint
+	}
+
+	logger.Info("provisioning-users-in-uaa", lager.Data{
+		"url": url,
+	})
+
+	httpClient := newHTTPClient(logger, config.CloudControllerConfig)
+	client := uaa.NewClient(url, config.UAAConfig.ClientID, config.UAAConfig.ClientSecret, httpClient)
+	client.BackOff = config.RetryConfig.Policy().NewBackOff
+	return client
+}
+
+// newPermSeeder returns a seeder for the configured Perm, whose calls are retried with the same backoff as Cloud Controller requests
+func newPermSeeder(logger lager.Logger, config cmd.PermConfig, retry cmd.RetryConfig) *perm.Seeder {
+	tlsConfig, err := perm.NewTLSConfig(config.CACert)
+	if err != nil {
+		logger.Error("failed-to-load-perm-ca-cert", err)
+		panic(err)
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = DefaultPermTimeout
+	}
+
+	client, err := perm.NewClient(config.Address, tlsConfig, timeout)
+	if err != nil {
+		logger.Error("failed-to-dial-perm", err)
+		panic(err)
+	}
+
+	seeder := perm.NewSeeder(client, config.ActorNamespace)
+	seeder.BackOff = retry.Policy().NewBackOff
+	return seeder
+}
+
+func serveMetrics(logger lager.Logger, addr string, registry *metrics.Registry) {
+	logger.Info("serving-metrics", lager.Data{
+		"address": addr,
+	})
+
+	err := metrics.Serve(addr, registry)
+	if err != nil {
+		logger.Error("failed-to-serve-metrics", err)
+	}
+}
+
+type progressGauges struct {
+	resources        *metrics.Gauge
+	concurrencyLimit *metrics.Gauge
+}
+
+// reportProgress periodically logs the concurrency limit and, if there is a Cloud Controller,
+// the number of orgs, spaces and users in it, until ctx is done
+func reportProgress(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, aimd *limiter.AIMD, gauges progressGauges) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		data := lager.Data{
+			"concurrency-limit": aimd.Limit(),
+			"workers-in-use":    aimd.InUse(),
+		}
+		gauges.concurrencyLimit.Set(float64(aimd.Limit()))
+
+		if cfClient != nil {
+			orgCount, _ := cf.OrgCount(ctx, logger, cfClient)
+			spaceCount, _ := cf.SpaceCount(ctx, logger, cfClient)
+			userCount, _ := cf.UserCount(ctx, logger, cfClient)
+
+			gauges.resources.Set(float64(orgCount), "org")
+			gauges.resources.Set(float64(spaceCount), "space")
+			gauges.resources.Set(float64(userCount), "user")
+
+			data["org-count"] = orgCount
+			data["space-count"] = spaceCount
+			data["user-count"] = userCount
+		}
+
+		logger.Info("progress", data)
+	}
 }
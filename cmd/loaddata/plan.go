@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/pivotal-cf/perm-test/cmd"
+)
+
+// plan writes the dataset that would be seeded to stdout, without contacting
+// Cloud Controller, and a summary of it to stderr
+func plan(args []string) {
+	flags := flag.NewFlagSet("plan", flag.ExitOnError)
+	format := flags.String("format", "json", "output format (json or csv)")
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		usage()
+	}
+
+	config := loadConfig(flags.Arg(0))
+	err := config.Validate()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid config: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	seed := seedFor(config)
+	p := cmd.NewPlan(config.TestDataConfig, seed)
+
+	switch *format {
+	case "json":
+		err = p.WriteJSON(os.Stdout)
+	case "csv":
+		err = p.WriteCSV(os.Stdout)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format: %s\n", *format)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write plan: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Fprintf(os.Stderr, "seed: %d\n", seed)
+	for _, env := range []struct {
+		name string
+		plan cmd.EnvironmentPlan
+	}{
+		{"test environment", p.TestEnvironment},
+		{"external environment", p.ExternalEnvironment},
+	} {
+		s := env.plan.Summary()
+		fmt.Fprintf(os.Stderr, "%s: %d orgs, %d spaces, %d apps, %d users, %d role assignments\n",
+			env.name, s.OrgCount, s.SpaceCount, s.AppCount, s.UserCount, s.RoleAssignmentCount)
+		for _, role := range append(cmd.OrgRoles, cmd.SpaceRoles...) {
+			if n := s.RoleCounts[role]; n > 0 {
+				fmt.Fprintf(os.Stderr, "  %s: %d\n", role, n)
+			}
+		}
+		fmt.Fprintf(os.Stderr, "  org associations saved: %d\n", s.OrgAssociationsSaved)
+		cmd.WriteHistogram(os.Stderr, "orgs per user:", s.OrgsPerUserHistogram)
+		cmd.WriteHistogram(os.Stderr, "spaces per user:", s.SpacesPerUserHistogram)
+		cmd.WriteHistogram(os.Stderr, "spaces per org:", s.SpacesPerOrgHistogram)
+		cmd.WriteHistogram(os.Stderr, "apps per space:", s.AppsPerSpaceHistogram)
+	}
+}
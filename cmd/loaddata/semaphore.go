@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+
+	"github.com/pivotal-cf/perm-test/metrics"
+)
+
+// Semaphore limits the number of seeding workers running at once
+//
+// *semaphore.Weighted and *limiter.AIMD satisfy this interface
+type Semaphore interface {
+	Acquire(ctx context.Context, n int64) error
+	Release(n int64)
+}
+
+// instrumentedSemaphore reports the number of workers holding the semaphore
+type instrumentedSemaphore struct {
+	sem      Semaphore
+	inFlight *metrics.Gauge
+}
+
+func newInstrumentedSemaphore(sem Semaphore, inFlight *metrics.Gauge) *instrumentedSemaphore {
+	return &instrumentedSemaphore{
+		sem:      sem,
+		inFlight: inFlight,
+	}
+}
+
+// Acquire fails once ctx is done, even if there are free workers,
+// so that no new work is started after seeding has been interrupted
+func (s *instrumentedSemaphore) Acquire(ctx context.Context, n int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	err := s.sem.Acquire(ctx, n)
+	if err != nil {
+		return err
+	}
+
+	s.inFlight.Add(float64(n))
+	return nil
+}
+
+func (s *instrumentedSemaphore) Release(n int64) {
+	s.inFlight.Add(-float64(n))
+	s.sem.Release(n)
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/cloudfoundry-community/go-cfclient"
+	"github.com/pivotal-cf/perm-test/cf"
+	"github.com/pivotal-cf/perm-test/cmd"
+	"github.com/pivotal-cf/perm-test/uaa"
+	"golang.org/x/sync/semaphore"
+)
+
+const JobPollInterval = 2 * time.Second
+
+// teardown deletes the seeded dataset from Cloud Controller
+//
+// Orgs are deleted if their name follows the plan's naming scheme or they are
+// recorded in the manifest. Users have no name in Cloud Controller, so are only
+// deleted if they are recorded in the manifest, planned from the configured seed,
+// or their username follows the naming scheme.
+// The test environment's user is never deleted. If users were provisioned in UAA,
+// those whose name follows the naming scheme are deleted from UAA as well.
+func teardown(args []string) {
+	flags := flag.NewFlagSet("teardown", flag.ExitOnError)
+	manifestPath := flags.String("manifest", "", "seeding manifest listing additional resources to delete (defaults to checkpoint_path)")
+	dryRun := flags.Bool("dry-run", false, "report what would be deleted without deleting it")
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		usage()
+	}
+
+	config := loadConfig(flags.Arg(0))
+	logger := config.NewLogger("perm-teardown")
+
+	if config.Backend == cmd.BackendPerm {
+		fmt.Fprintln(os.Stderr, "teardown only supports the cloud_controller backend")
+		os.Exit(1)
+	}
+
+	if *manifestPath == "" {
+		*manifestPath = config.CheckpointPath
+	}
+
+	manifest := cmd.NewManifest(nil)
+	if *manifestPath != "" {
+		var err error
+		manifest, err = cmd.LoadManifest(*manifestPath)
+		if os.IsNotExist(err) {
+			logger.Info("manifest-not-found", lager.Data{
+				"path": *manifestPath,
+			})
+			manifest = cmd.NewManifest(nil)
+		} else if err != nil {
+			logger.Error("failed-to-load-manifest", err)
+			panic(err)
+		}
+	}
+
+	ctx, stop := cmd.WithInterrupt(context.Background(), logger)
+	defer stop()
+
+	setRateLimit(logger, config.RateLimitConfig)
+	setRetryPolicy(logger, config.RetryConfig)
+	cfClient := newCFClient(logger, config.CloudControllerConfig, newHTTPClient(logger, config.CloudControllerConfig))
+
+	var uaaClient *uaa.Client
+	if config.UAAConfig.Enabled() {
+		uaaClient = newUAAClient(logger, config, cfClient)
+	}
+
+	allOrgs, err := cf.ListOrgs(ctx, logger, cfClient)
+	if err != nil {
+		panic(err)
+	}
+	allUsers, err := cf.ListUsers(ctx, logger, cfClient)
+	if err != nil {
+		panic(err)
+	}
+
+	var planned []cmd.UserPlan
+	if config.TestDataConfig.Seed != 0 {
+		planned = cmd.NewPlan(config.TestDataConfig, config.TestDataConfig.Seed).ExternalEnvironment.Users
+	} else if len(manifest.Entries()) == 0 {
+		logger.Info("no-manifest-or-seed")
+		fmt.Fprintln(os.Stderr, "WARNING: there is no manifest and no seed, so users created without a UAA username will not be deleted")
+	}
+
+	orgs, users := selectTeardown(allOrgs, allUsers, manifest, planned, config.TestDataConfig.TestEnvironmentConfig.UserGUID)
+
+	logger.Info("starting", lager.Data{
+		"orgs":    len(orgs),
+		"users":   len(users),
+		"dry-run": *dryRun,
+	})
+
+	if *dryRun {
+		for _, org := range orgs {
+			logger.Info("would-delete-org", lager.Data{"name": org.Name, "guid": org.Guid})
+		}
+		for _, user := range users {
+			logger.Info("would-delete-user", lager.Data{"guid": user.Guid})
+		}
+		return
+	}
+
+	deletedOrgs, deletedUsers := deleteDataset(ctx, logger, concurrencyFor(config), cfClient, uaaClient, orgs, users)
+
+	logger.Info("finished", lager.Data{
+		"deleted-orgs":  deletedOrgs,
+		"deleted-users": deletedUsers,
+		"failed-orgs":   len(orgs) - deletedOrgs,
+		"failed-users":  len(users) - deletedUsers,
+		"interrupted":   ctx.Err() != nil,
+	})
+
+	if ctx.Err() != nil {
+		os.Exit(cmd.InterruptExitCode)
+	}
+	if deletedOrgs != len(orgs) || deletedUsers != len(users) {
+		os.Exit(1)
+	}
+}
+
+// selectTeardown returns the orgs and users which belong to the seeded dataset,
+// leaving out the user with testUserGUID
+//
+// Users without a username in Cloud Controller are given the name they were recorded with in the manifest,
+// or planned with, so that deleteDataset can tell which were created by this tool.
+func selectTeardown(orgs []cfclient.Org, users []cfclient.User, manifest *cmd.Manifest, planned []cmd.UserPlan, testUserGUID string) ([]cfclient.Org, []cfclient.User) {
+	manifestOrgs := make(map[string]bool)
+	manifestUsers := make(map[string]string)
+	for _, u := range planned {
+		manifestUsers[u.GUID] = u.Name
+	}
+	for _, e := range manifest.Entries() {
+		switch e.Type {
+		case cmd.ManifestEntryOrg:
+			manifestOrgs[e.GUID] = true
+		case cmd.ManifestEntryUser:
+			manifestUsers[e.GUID] = e.Name
+		}
+	}
+
+	var selectedOrgs []cfclient.Org
+	for _, org := range orgs {
+		if cmd.IsSeededName(org.Name) || manifestOrgs[org.Guid] {
+			selectedOrgs = append(selectedOrgs, org)
+		}
+	}
+
+	var selectedUsers []cfclient.User
+	for _, user := range users {
+		if user.Guid == testUserGUID {
+			continue
+		}
+
+		name, recorded := manifestUsers[user.Guid]
+		if !cmd.IsSeededName(user.Username) && !recorded {
+			continue
+		}
+
+		if user.Username == "" {
+			user.Username = name
+		}
+		selectedUsers = append(selectedUsers, user)
+	}
+
+	return selectedOrgs, selectedUsers
+}
+
+// deleteDataset deletes the orgs, waiting for their recursive deletes to finish, then the users
+// from Cloud Controller and, if uaaClient is not nil, those whose username follows the naming scheme from UAA
+//
+// It returns the number of orgs and users successfully deleted.
+// Once ctx is done no more deletes are started.
+func deleteDataset(ctx context.Context, logger lager.Logger, concurrency int64, cfClient *cfclient.Client, uaaClient *uaa.Client, orgs []cfclient.Org, users []cfclient.User) (int, int) {
+	sem := semaphore.NewWeighted(concurrency)
+
+	var (
+		mu           sync.Mutex
+		deletedOrgs  int
+		deletedUsers int
+		wg           sync.WaitGroup
+	)
+
+	for _, org := range orgs {
+		err := sem.Acquire(ctx, 1)
+		if err != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(org cfclient.Org) {
+			defer sem.Release(1)
+			defer wg.Done()
+
+			logger := logger.Session("delete-org", lager.Data{
+				"name": org.Name,
+				"guid": org.Guid,
+			})
+
+			err := cf.DeleteOrg(ctx, logger, cfClient, org.Guid, JobPollInterval)
+			if err != nil {
+				return
+			}
+
+			logger.Info("deleted")
+			mu.Lock()
+			deletedOrgs++
+			mu.Unlock()
+		}(org)
+	}
+	wg.Wait()
+
+	for _, user := range users {
+		err := sem.Acquire(ctx, 1)
+		if err != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(user cfclient.User) {
+			defer sem.Release(1)
+			defer wg.Done()
+
+			logger := logger.Session("delete-user", lager.Data{
+				"guid": user.Guid,
+			})
+
+			err := cf.DeleteUser(ctx, logger, cfClient, user.Guid)
+			if err != nil {
+				return
+			}
+
+			if uaaClient != nil && cmd.IsSeededName(user.Username) {
+				err = uaaClient.DeleteUser(ctx, logger, user.Guid)
+				if err != nil {
+					return
+				}
+			}
+
+			logger.Info("deleted")
+			mu.Lock()
+			deletedUsers++
+			mu.Unlock()
+		}(user)
+	}
+	wg.Wait()
+
+	return deletedOrgs, deletedUsers
+}
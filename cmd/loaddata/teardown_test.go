@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/pivotal-cf/perm-test/cmd"
+	"github.com/pivotal-cf/perm-test/uaa"
+)
+
+var _ = Describe("selectTeardown", func() {
+	var (
+		orgs  []cfclient.Org
+		users []cfclient.User
+	)
+
+	BeforeEach(func() {
+		orgs = []cfclient.Org{
+			{Name: "system", Guid: "system-guid"},
+			{Name: "perm-test-org-0", Guid: "test-org-guid"},
+			{Name: "perm-external-org-0", Guid: "external-org-guid"},
+			{Name: "renamed-org", Guid: "renamed-org-guid"},
+		}
+		users = []cfclient.User{
+			{Username: "admin", Guid: "admin-guid"},
+			{Guid: "seeded-user-guid"},
+			{Guid: "other-user-guid"},
+		}
+	})
+
+	It("selects only orgs following the naming scheme when there is no manifest", func() {
+		selectedOrgs, selectedUsers := selectTeardown(orgs, users, cmd.NewManifest(nil), nil, "")
+
+		Expect(selectedOrgs).To(ConsistOf(orgs[1], orgs[2]))
+		Expect(selectedUsers).To(BeEmpty())
+	})
+
+	It("also selects the orgs and users recorded in the manifest", func() {
+		manifest := cmd.NewManifest(nil)
+		Expect(manifest.RecordOrg("renamed-org", "renamed-org-guid")).To(Succeed())
+		Expect(manifest.RecordUser("perm-external-user-0", "seeded-user-guid")).To(Succeed())
+
+		selectedOrgs, selectedUsers := selectTeardown(orgs, users, manifest, nil, "")
+
+		Expect(selectedOrgs).To(ConsistOf(orgs[1], orgs[2], orgs[3]))
+		Expect(selectedUsers).To(ConsistOf(cfclient.User{Username: "perm-external-user-0", Guid: "seeded-user-guid"}))
+	})
+
+	It("also selects the users planned from the seed", func() {
+		planned := []cmd.UserPlan{{Name: "perm-external-user-0", GUID: "seeded-user-guid"}}
+
+		_, selectedUsers := selectTeardown(orgs, users, cmd.NewManifest(nil), planned, "")
+
+		Expect(selectedUsers).To(ConsistOf(cfclient.User{Username: "perm-external-user-0", Guid: "seeded-user-guid"}))
+	})
+})
+
+var _ = Describe("teardown", func() {
+	var (
+		ccServer  *ghttp.Server
+		uaaServer *ghttp.Server
+
+		cfClient  *cfclient.Client
+		uaaClient *uaa.Client
+		logger    *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		ccServer = ghttp.NewServer()
+		ccServer.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/info"),
+			ghttp.RespondWith(200, "{}", nil),
+		))
+
+		var err error
+		cfClient, err = cfclient.NewClient(&cfclient.Config{
+			ApiAddress: "http://" + ccServer.Addr(),
+			Token:      "foobar",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		uaaServer = ghttp.NewServer()
+		uaaServer.RouteToHandler("POST", "/oauth/token", ghttp.RespondWithJSONEncoded(http.StatusOK, map[string]interface{}{
+			"access_token": "some-token",
+			"token_type":   "bearer",
+			"expires_in":   3600,
+		}))
+		uaaClient = uaa.NewClient(uaaServer.URL(), "some-client", "some-secret", http.DefaultClient)
+
+		logger = lagertest.NewTestLogger("teardown")
+	})
+
+	AfterEach(func() {
+		ccServer.Close()
+		uaaServer.Close()
+	})
+
+	It("deletes neither the test user nor users it did not create from UAA", func() {
+		users := []cfclient.User{
+			{Username: "perm-test-user", Guid: "test-user-guid"},
+			{Guid: "seeded-user-guid"},
+			{Guid: "operator-user-guid"},
+		}
+		manifest := cmd.NewManifest(nil)
+		Expect(manifest.RecordUser("perm-test-user", "test-user-guid")).To(Succeed())
+		Expect(manifest.RecordUser("perm-external-user-0", "seeded-user-guid")).To(Succeed())
+		Expect(manifest.RecordUser("operator", "operator-user-guid")).To(Succeed())
+
+		ccServer.RouteToHandler("DELETE", "/v2/users/seeded-user-guid", ghttp.RespondWith(http.StatusNoContent, nil))
+		ccServer.RouteToHandler("DELETE", "/v2/users/operator-user-guid", ghttp.RespondWith(http.StatusNoContent, nil))
+		uaaServer.RouteToHandler("DELETE", "/Users/seeded-user-guid", ghttp.RespondWith(http.StatusOK, "{}"))
+
+		_, selectedUsers := selectTeardown(nil, users, manifest, nil, "test-user-guid")
+		deletedOrgs, deletedUsers := deleteDataset(context.Background(), logger, 1, cfClient, uaaClient, nil, selectedUsers)
+
+		Expect(deletedOrgs).To(Equal(0))
+		Expect(deletedUsers).To(Equal(2))
+
+		var deleted []string
+		for _, req := range append(ccServer.ReceivedRequests(), uaaServer.ReceivedRequests()...) {
+			if req.Method == "DELETE" {
+				deleted = append(deleted, req.Host+req.URL.Path)
+			}
+		}
+		Expect(deleted).To(ConsistOf(
+			ccServer.Addr()+"/v2/users/seeded-user-guid",
+			ccServer.Addr()+"/v2/users/operator-user-guid",
+			uaaServer.Addr()+"/Users/seeded-user-guid",
+		))
+	})
+})
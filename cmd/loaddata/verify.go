@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/cloudfoundry-community/go-cfclient"
+	"github.com/pivotal-cf/perm-test/cf"
+	"github.com/pivotal-cf/perm-test/cmd"
+	"github.com/pivotal-cf/perm-test/uaa"
+)
+
+// verify audits the seeded dataset in Cloud Controller against the config,
+// writing a JSON report of any drift to stdout
+//
+// Role memberships of the external users can only be checked if the config sets a seed,
+// as otherwise their GUIDs cannot be regenerated, and nothing can be checked without one
+// if orgs and spaces are sized from distributions. If users were provisioned in UAA,
+// their GUIDs are looked up there by name instead.
+func verify(args []string) {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	flags.Parse(args)
+
+	if flags.NArg() < 1 {
+		usage()
+	}
+
+	config := loadConfig(flags.Arg(0))
+	logger := config.NewStderrLogger("perm-verify")
+
+	err := config.Validate()
+	if err != nil {
+		logger.Error("failed-to-validate-config", err)
+		panic(err)
+	}
+
+	if config.Backend == cmd.BackendPerm {
+		fmt.Fprintln(os.Stderr, "verify only supports the cloud_controller backend")
+		os.Exit(1)
+	}
+
+	data := config.TestDataConfig
+	if data.Seed == 0 && (!data.SpacesPerOrgDistribution.IsZero() || !data.AppsPerSpaceDistribution.IsZero()) {
+		fmt.Fprintln(os.Stderr, "verify needs a seed to regenerate sizes sampled from spaces_per_org_distribution or apps_per_space_distribution")
+		os.Exit(1)
+	}
+
+	ctx, stop := cmd.WithInterrupt(context.Background(), logger)
+	defer stop()
+
+	setRateLimit(logger, config.RateLimitConfig)
+	setRetryPolicy(logger, config.RetryConfig)
+	cfClient := newCFClient(logger, config.CloudControllerConfig, newHTTPClient(logger, config.CloudControllerConfig))
+	plan := cmd.NewPlan(config.TestDataConfig, seedFor(config))
+
+	if config.UAAConfig.Enabled() && config.TestDataConfig.Seed != 0 {
+		err = resolveUserGUIDs(ctx, logger, newUAAClient(logger, config, cfClient), plan.ExternalEnvironment.Users)
+		if err != nil {
+			logger.Error("failed-to-resolve-uaa-users", err)
+			os.Exit(1)
+		}
+	}
+
+	users := plan.TestEnvironment.Users
+	if config.TestDataConfig.Seed != 0 {
+		users = append(users, plan.ExternalEnvironment.Users...)
+	} else {
+		logger.Info("skipping-external-user-roles", lager.Data{
+			"reason": "no seed in config",
+		})
+	}
+
+	inv, err := takeInventory(ctx, logger, cfClient, users)
+	if err != nil {
+		logger.Error("failed-to-take-inventory", err)
+		os.Exit(1)
+	}
+
+	report := cmd.Verify(plan, inv)
+	err = report.WriteJSON(os.Stdout)
+	if err != nil {
+		logger.Error("failed-to-write-report", err)
+		os.Exit(1)
+	}
+
+	logger.Info("finished", lager.Data{
+		"ok":    report.OK,
+		"drift": len(report.Drift),
+	})
+
+	if !report.OK {
+		os.Exit(1)
+	}
+}
+
+// The V2 endpoints listing the orgs and spaces in which a user has each role
+var (
+	userOrgRoleEndpoints = []struct{ role, endpoint string }{
+		{cmd.RoleOrgUser, "organizations"},
+		{cmd.RoleOrgManager, "managed_organizations"},
+		{cmd.RoleOrgBillingManager, "billing_managed_organizations"},
+		{cmd.RoleOrgAuditor, "audited_organizations"},
+	}
+	userSpaceRoleEndpoints = []struct{ role, endpoint string }{
+		{cmd.RoleSpaceDeveloper, "spaces"},
+		{cmd.RoleSpaceManager, "managed_spaces"},
+		{cmd.RoleSpaceAuditor, "audited_spaces"},
+	}
+)
+
+// resolveUserGUIDs replaces the planned GUID of each user with its GUID in UAA
+//
+// Users missing from UAA keep their planned GUID, which Cloud Controller will not know either,
+// so they are reported as missing users along with all of their roles
+func resolveUserGUIDs(ctx context.Context, logger lager.Logger, uaaClient *uaa.Client, users []cmd.UserPlan) error {
+	for i := range users {
+		guid, err := uaaClient.UserID(ctx, logger, users[i].Name)
+		if err == uaa.ErrUserNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		users[i].GUID = guid
+	}
+
+	return nil
+}
+
+// takeInventory lists every seeded org, space and app, and the org and space
+// memberships of the users
+//
+// A user which does not exist in Cloud Controller is recorded as missing, with no roles
+func takeInventory(ctx context.Context, logger lager.Logger, cfClient *cfclient.Client, users []cmd.UserPlan) (*cmd.Inventory, error) {
+	inv := cmd.NewInventory()
+
+	orgs, err := cf.WalkV3(ctx, logger.Session("list-orgs"), cfClient, "/v3/organizations?per_page=5000")
+	if err != nil {
+		return nil, err
+	}
+	spaces, err := cf.WalkV3(ctx, logger.Session("list-spaces"), cfClient, "/v3/spaces?per_page=5000")
+	if err != nil {
+		return nil, err
+	}
+	apps, err := cf.WalkV3(ctx, logger.Session("list-apps"), cfClient, "/v3/apps?per_page=5000")
+	if err != nil {
+		return nil, err
+	}
+
+	orgNames := make(map[string]string)
+	for _, org := range orgs {
+		if cmd.IsSeededName(org.Name) {
+			orgNames[org.GUID] = org.Name
+			inv.Orgs[org.Name] = make(map[string]int)
+		}
+	}
+
+	spaceNames := make(map[string]string)
+	spaceOrgs := make(map[string]string)
+	for _, space := range spaces {
+		orgName, ok := orgNames[space.ParentGUID]
+		if !ok {
+			continue
+		}
+
+		spaceNames[space.GUID] = space.Name
+		spaceOrgs[space.GUID] = orgName
+		inv.Orgs[orgName][space.Name] = 0
+	}
+
+	for _, app := range apps {
+		spaceName, ok := spaceNames[app.ParentGUID]
+		if !ok {
+			continue
+		}
+
+		inv.Orgs[spaceOrgs[app.ParentGUID]][spaceName]++
+	}
+
+	for _, u := range users {
+		inv.CheckedUsers[u.GUID] = true
+
+		for _, r := range userOrgRoleEndpoints {
+			userOrgs, err := cf.WalkV2(ctx, logger.Session("list-user-orgs"), cfClient, fmt.Sprintf("/v2/users/%s/%s?results-per-page=100", u.GUID, r.endpoint))
+			if cf.IsUserNotFound(err) {
+				inv.MissingUsers[u.GUID] = true
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			for _, org := range userOrgs {
+				inv.Roles[cmd.RoleAssignment{UserGUID: u.GUID, Role: r.role, OrgName: org.Name}] = true
+			}
+		}
+
+		if inv.MissingUsers[u.GUID] {
+			continue
+		}
+
+		for _, r := range userSpaceRoleEndpoints {
+			userSpaces, err := cf.WalkV2(ctx, logger.Session("list-user-spaces"), cfClient, fmt.Sprintf("/v2/users/%s/%s?results-per-page=100", u.GUID, r.endpoint))
+			if err != nil {
+				return nil, err
+			}
+			for _, space := range userSpaces {
+				orgName, ok := spaceOrgs[space.GUID]
+				if !ok {
+					continue
+				}
+				inv.Roles[cmd.RoleAssignment{UserGUID: u.GUID, Role: r.role, OrgName: orgName, SpaceName: space.Name}] = true
+			}
+		}
+	}
+
+	return inv, nil
+}
@@ -1,33 +1,349 @@
 package cmd
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
 	"os"
+	"time"
 
 	"code.cloudfoundry.org/lager"
+	"github.com/pivotal-cf/perm-test/cf"
+)
+
+const redacted = "[REDACTED]"
+
+const (
+	BackendCloudController = "cloud_controller"
+	BackendPerm            = "perm"
+)
+
+const (
+	APIVersionV2 = "v2"
+	APIVersionV3 = "v3"
 )
 
 type LoadDataConfig struct {
-	LogLevel              string                `yaml:"log_level"`
+	LogLevel       string `yaml:"log_level"`
+	CheckpointPath string `yaml:"checkpoint_path"`
+	ReportPath     string `yaml:"report_path"`
+
+	// Concurrency is the initial number of seeding workers, defaulting to 12
+	Concurrency int `yaml:"concurrency"`
+
+	AdaptiveConcurrencyConfig AdaptiveConcurrencyConfig `yaml:"adaptive_concurrency"`
+	RateLimitConfig           RateLimitConfig           `yaml:"rate_limit"`
+	FailureBudgetConfig       FailureBudgetConfig       `yaml:"failure_budget"`
+	RetryConfig               RetryConfig               `yaml:"retry"`
+
+	// MetricsAddress, if set, is the host:port on which Prometheus metrics are served at /metrics
+	MetricsAddress string `yaml:"metrics_address"`
+
+	// Backend is where role assignments are seeded, either cloud_controller (the default) or perm
+	Backend string `yaml:"backend"`
+
 	CloudControllerConfig CloudControllerConfig `yaml:"cloud_controller"`
+	PermConfig            PermConfig            `yaml:"perm"`
+	UAAConfig             UAAConfig             `yaml:"uaa"`
 	TestDataConfig        TestDataConfig        `yaml:"test_data"`
 }
 
+// AdaptiveConcurrencyConfig bounds the number of seeding workers, which grows while
+// Cloud Controller responds quickly and successfully and shrinks when it does not
+//
+// Min defaults to 1, Max to four times the initial concurrency and LatencyThreshold,
+// above which a response counts as slow, to 1s.
+type AdaptiveConcurrencyConfig struct {
+	Min              int           `yaml:"min"`
+	Max              int           `yaml:"max"`
+	LatencyThreshold time.Duration `yaml:"latency_threshold"`
+}
+
+// FailureBudgetConfig is how many orgs and users may fail to be seeded, once their
+// operations have been retried, before seeding stops
+//
+// The larger of MaxFailures and MaxFailurePercent of the planned orgs and users is allowed.
+// Like percent_users, MaxFailurePercent is a fraction between 0 and 1.
+// By default no failures are tolerated.
+type FailureBudgetConfig struct {
+	MaxFailures       int     `yaml:"max_failures"`
+	MaxFailurePercent float64 `yaml:"max_failure_percent"`
+}
+
+// Allowed returns the number of failures tolerated when seeding total orgs and users
+func (c FailureBudgetConfig) Allowed(total int) int {
+	allowed := int(math.Floor(c.MaxFailurePercent * float64(total)))
+	if c.MaxFailures > allowed {
+		allowed = c.MaxFailures
+	}
+	return allowed
+}
+
+// RetryConfig is how requests to Cloud Controller which fail are retried
+//
+// Anything not set keeps the value from cf.DefaultRetryPolicy. Jitter is the
+// randomization factor applied to each interval, e.g. 0.5 for +/- 50%.
+// If set, only responses with one of RetryableStatusCodes and Cloud Foundry errors
+// with one of RetryableErrorCodes, e.g. 10001 for CF-ServerError, are retried.
+type RetryConfig struct {
+	MaxElapsedTime  time.Duration `yaml:"max_elapsed_time"`
+	InitialInterval time.Duration `yaml:"initial_interval"`
+	MaxInterval     time.Duration `yaml:"max_interval"`
+	Multiplier      float64       `yaml:"multiplier"`
+	Jitter          float64       `yaml:"jitter"`
+
+	RetryableStatusCodes []int `yaml:"retryable_status_codes"`
+	RetryableErrorCodes  []int `yaml:"retryable_error_codes"`
+}
+
+// Policy returns the retry policy described by the config
+func (c RetryConfig) Policy() cf.RetryPolicy {
+	p := cf.DefaultRetryPolicy()
+	if c.MaxElapsedTime != 0 {
+		p.MaxElapsedTime = c.MaxElapsedTime
+	}
+	if c.InitialInterval != 0 {
+		p.InitialInterval = c.InitialInterval
+	}
+	if c.MaxInterval != 0 {
+		p.MaxInterval = c.MaxInterval
+	}
+	if c.Multiplier != 0 {
+		p.Multiplier = c.Multiplier
+	}
+	if c.Jitter != 0 {
+		p.RandomizationFactor = c.Jitter
+	}
+	if len(c.RetryableStatusCodes) > 0 {
+		p.RetryableStatusCodes = c.RetryableStatusCodes
+	}
+	if len(c.RetryableErrorCodes) > 0 {
+		p.RetryableErrorCodes = c.RetryableErrorCodes
+	}
+	return p
+}
+
+// RateLimitConfig caps the requests per second made to Cloud Controller, in total
+// and for each operation, e.g. create_app. Retried requests count towards the limits.
+//
+// A rate of 0 is unlimited. Burst defaults to 1.
+type RateLimitConfig struct {
+	RequestsPerSecond float64            `yaml:"requests_per_second"`
+	Burst             int                `yaml:"burst"`
+	Operations        map[string]float64 `yaml:"operations"`
+}
+
+// Enabled returns true if any rate is limited
+func (c RateLimitConfig) Enabled() bool {
+	return c.RequestsPerSecond > 0 || len(c.Operations) > 0
+}
+
 type CloudControllerConfig struct {
 	URL          string `yaml:"url"`
 	ClientID     string `yaml:"client_id"`
 	ClientSecret string `yaml:"client_secret"`
-	CACert       string `yaml:"ca_cert"`
+
+	// Timeout is the timeout of each request, defaulting to a value chosen by the command
+	Timeout time.Duration `yaml:"timeout"`
+
+	// CACert is the PEM-encoded CA certificate Cloud Controller and UAA are verified against.
+	// If it is not set certificates are not verified, unless StrictTLS is set, in which case
+	// they are verified against the system roots.
+	CACert    string `yaml:"ca_cert"`
+	StrictTLS bool   `yaml:"strict_tls"`
+
+	// APIVersion is the version of the API seeding uses, v2 (the default) or v3
+	APIVersion string `yaml:"api_version"`
+
+	// V3Roles assigns roles with the V3 API, which needs Cloud Controller API 3.72 or later,
+	// even if APIVersion is v2
+	V3Roles bool `yaml:"v3_roles"`
+}
+
+// SkipSSLValidation returns true if certificates should not be verified
+func (c CloudControllerConfig) SkipSSLValidation() bool {
+	return c.CACert == "" && !c.StrictTLS
+}
+
+// NewTLSConfig returns the TLS config for connecting to Cloud Controller and UAA
+func (c CloudControllerConfig) NewTLSConfig() (*tls.Config, error) {
+	if c.CACert == "" {
+		return &tls.Config{
+			InsecureSkipVerify: c.SkipSSLValidation(),
+		}, nil
+	}
+
+	caCert, err := ioutil.ReadFile(c.CACert)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("no certificates found in %s", c.CACert)
+	}
+
+	return &tls.Config{
+		RootCAs: pool,
+	}, nil
+}
+
+type PermConfig struct {
+	Address        string `yaml:"address"`
+	CACert         string `yaml:"ca_cert"`
+	ActorNamespace string `yaml:"actor_namespace"`
+
+	// Timeout is the timeout of each request, defaulting to a value chosen by the command
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// UAAConfig, if a client is given, provisions the external users in UAA
+// so that they can log in with UserPassword
+type UAAConfig struct {
+	// URL defaults to the token endpoint advertised by Cloud Controller
+	URL          string `yaml:"url"`
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	UserPassword string `yaml:"user_password"`
+}
+
+// Enabled returns true if users should be provisioned in UAA
+func (c UAAConfig) Enabled() bool {
+	return c.ClientID != ""
 }
 
 type TestDataConfig struct {
+	// Seed makes the generated dataset reproducible. If it is 0 a seed is chosen from the current time.
+	Seed int64 `yaml:"seed"`
+
 	AppsPerSpaceCount int `yaml:"apps_per_space_count"`
 	SpacesPerOrgCount int `yaml:"spaces_per_org_count"`
 
+	// SpacesPerOrgDistribution and AppsPerSpaceDistribution, if set, size each org and space
+	// by sampling them instead of using SpacesPerOrgCount and AppsPerSpaceCount
+	SpacesPerOrgDistribution SizeDistribution `yaml:"spaces_per_org_distribution"`
+	AppsPerSpaceDistribution SizeDistribution `yaml:"apps_per_space_distribution"`
+
+	// AppShapeConfig is what is created with every app besides its record
+	AppShapeConfig AppShapeConfig `yaml:"app_shape"`
+
 	TestEnvironmentConfig     TestEnvironmentConfig     `yaml:"test_environment"`
 	ExternalEnvironmentConfig ExternalEnvironmentConfig `yaml:"external_environment"`
 }
 
+// SizeDistribution is how the number of spaces in an org, or apps in a space, is sampled
+//
+// Exactly one of Buckets, Zipf and LogNormal is set, or none, in which case the fixed count is used.
+type SizeDistribution struct {
+	Buckets   []SizeBucket           `yaml:"buckets"`
+	Zipf      *ZipfDistribution      `yaml:"zipf"`
+	LogNormal *LogNormalDistribution `yaml:"log_normal"`
+}
+
+// SizeBucket is the share of orgs or spaces, Percent, which are given Count spaces or apps
+type SizeBucket struct {
+	Percent float64 `yaml:"percent"`
+	Count   int     `yaml:"count"`
+}
+
+// ZipfDistribution samples sizes from 0 to Max with probability proportional to (V + size) ^ -S,
+// so most are small and a few are very large
+type ZipfDistribution struct {
+	S   float64 `yaml:"s"`
+	V   float64 `yaml:"v"`
+	Max int     `yaml:"max"`
+}
+
+// LogNormalDistribution samples sizes whose natural logarithm is normally distributed
+// with mean Mu and standard deviation Sigma, rounded and capped at Max if it is not 0
+type LogNormalDistribution struct {
+	Mu    float64 `yaml:"mu"`
+	Sigma float64 `yaml:"sigma"`
+	Max   int     `yaml:"max"`
+}
+
+// IsZero returns true if no distribution is set
+func (d SizeDistribution) IsZero() bool {
+	return len(d.Buckets) == 0 && d.Zipf == nil && d.LogNormal == nil
+}
+
+func (d SizeDistribution) validate() error {
+	set := 0
+	if len(d.Buckets) > 0 {
+		set++
+
+		var p float64
+		for _, b := range d.Buckets {
+			if b.Count < 0 || b.Percent < 0 {
+				return errors.New("bucket percent and count must not be negative")
+			}
+			p += b.Percent
+		}
+		if math.Abs(p-1) > 1e-9 {
+			return errors.New("bucket percentages must sum to 1")
+		}
+	}
+	if d.Zipf != nil {
+		set++
+
+		if d.Zipf.S <= 1 || d.Zipf.V < 1 || d.Zipf.Max < 1 {
+			return errors.New("zipf needs s greater than 1, v of at least 1 and a positive max")
+		}
+	}
+	if d.LogNormal != nil {
+		set++
+
+		if d.LogNormal.Sigma < 0 || d.LogNormal.Max < 0 {
+			return errors.New("log_normal sigma and max must not be negative")
+		}
+	}
+	if set > 1 {
+		return errors.New("only one of buckets, zipf and log_normal may be set")
+	}
+	return nil
+}
+
+// AppShapeConfig is the routes, service instances, environment variables and process scale of each app
+//
+// Routes are created on the shared domain SharedDomain, and service instances of the plan ServicePlan
+// of the service offering ServiceOffering, which must be registered by a service broker beforehand.
+// Instances and MemoryInMB, if not 0, scale each app's web process.
+type AppShapeConfig struct {
+	RoutesPerApp int    `yaml:"routes_per_app"`
+	SharedDomain string `yaml:"shared_domain"`
+
+	ServiceInstancesPerApp int    `yaml:"service_instances_per_app"`
+	ServiceOffering        string `yaml:"service_offering"`
+	ServicePlan            string `yaml:"service_plan"`
+
+	EnvVarsPerApp int `yaml:"env_vars_per_app"`
+
+	Instances  int `yaml:"instances"`
+	MemoryInMB int `yaml:"memory_in_mb"`
+}
+
+// IsZero returns true if apps are created without anything else
+func (c AppShapeConfig) IsZero() bool {
+	return c == AppShapeConfig{}
+}
+
+func (c AppShapeConfig) validate() error {
+	if c.RoutesPerApp < 0 || c.ServiceInstancesPerApp < 0 || c.EnvVarsPerApp < 0 || c.Instances < 0 || c.MemoryInMB < 0 {
+		return errors.New("counts must not be negative")
+	}
+	if c.RoutesPerApp > 0 && c.SharedDomain == "" {
+		return errors.New("shared_domain must be provided with routes_per_app")
+	}
+	if c.ServiceInstancesPerApp > 0 && (c.ServiceOffering == "" || c.ServicePlan == "") {
+		return errors.New("service_offering and service_plan must be provided with service_instances_per_app")
+	}
+	return nil
+}
+
 type TestEnvironmentConfig struct {
 	UserGUID string `yaml:"user_guid"`
 	OrgCount int    `yaml:"org_count"`
@@ -38,22 +354,95 @@ type ExternalEnvironmentConfig struct {
 	UserCount              int                     `yaml:"user_count"`
 	UserOrgDistributions   []UserOrgDistribution   `yaml:"user_org_distribution"`
 	UserSpaceDistributions []UserSpaceDistribution `yaml:"user_space_distribution"`
+
+	// OrgSelection and SpaceSelection are how each user's orgs and spaces are chosen,
+	// defaulting to SelectionWindow. SpaceSelection may also be SelectionLocality.
+	OrgSelection   string `yaml:"org_selection"`
+	SpaceSelection string `yaml:"space_selection"`
+
+	// ZipfExponent is how strongly SelectionZipf favours the most popular orgs and spaces, defaulting to 1
+	ZipfExponent float64 `yaml:"zipf_exponent"`
+}
+
+// Strategies for choosing the orgs and spaces of each user
+const (
+	SelectionWindow   = "window"
+	SelectionUniform  = "uniform"
+	SelectionZipf     = "zipf"
+	SelectionLocality = "locality"
+)
+
+// zipfExponent returns the configured exponent, or the default
+func (c ExternalEnvironmentConfig) zipfExponent() float64 {
+	if c.ZipfExponent == 0 {
+		return 1
+	}
+	return c.ZipfExponent
 }
 
 type UserOrgDistribution struct {
 	PercentUsers float64 `yaml:"percent_users"`
 	NumOrgs      int     `yaml:"num_orgs"`
+
+	// RoleMix is the share of the bucket's org assignments given each org role.
+	// If it is empty every assignment is org_user.
+	RoleMix []RoleWeight `yaml:"role_mix"`
 }
 
 type UserSpaceDistribution struct {
 	PercentUsers float64 `yaml:"percent_users"`
 	NumSpaces    int     `yaml:"num_spaces"`
+
+	// RoleMix is the share of the bucket's space assignments given each space role.
+	// If it is empty every assignment is space_developer.
+	RoleMix []RoleWeight `yaml:"role_mix"`
+}
+
+type RoleWeight struct {
+	Role    string  `yaml:"role"`
+	Percent float64 `yaml:"percent"`
+}
+
+// Redacted returns a copy of the config without the client secrets or user password, suitable for including in a report
+func (c LoadDataConfig) Redacted() LoadDataConfig {
+	if c.CloudControllerConfig.ClientSecret != "" {
+		c.CloudControllerConfig.ClientSecret = redacted
+	}
+	if c.UAAConfig.ClientSecret != "" {
+		c.UAAConfig.ClientSecret = redacted
+	}
+	if c.UAAConfig.UserPassword != "" {
+		c.UAAConfig.UserPassword = redacted
+	}
+	return c
+}
+
+// Personas returns up to n users from each bucket of the external environment, and the password they log in with
+func (c LoadDataConfig) Personas(n int) ([]Personas, string, error) {
+	if c.TestDataConfig.Seed == 0 {
+		return nil, "", errors.New("error in test_data: a seed is required to regenerate the users")
+	}
+	if !c.UAAConfig.Enabled() {
+		return nil, "", errors.New("error in uaa: users must be provisioned in uaa to log in")
+	}
+
+	plan := NewPlan(c.TestDataConfig, c.TestDataConfig.Seed)
+	return plan.ExternalEnvironment.Personas(n), c.UAAConfig.UserPassword, nil
 }
 
 func (c *LoadDataConfig) NewLogger(component string) lager.Logger {
+	return newLogger(component, c.LogLevel, os.Stdout)
+}
+
+// NewStderrLogger returns a logger which writes to stderr, leaving stdout free for a command's output
+func (c *LoadDataConfig) NewStderrLogger(component string) lager.Logger {
+	return newLogger(component, c.LogLevel, os.Stderr)
+}
+
+func newLogger(component string, logLevel string, w io.Writer) lager.Logger {
 	var l lager.LogLevel
 
-	switch c.LogLevel {
+	switch logLevel {
 	case "debug":
 		l = lager.DEBUG
 	case "info":
@@ -66,7 +455,7 @@ func (c *LoadDataConfig) NewLogger(component string) lager.Logger {
 		l = lager.INFO
 	}
 
-	sink := lager.NewWriterSink(os.Stdout, l)
+	sink := lager.NewWriterSink(w, l)
 	logger := lager.NewLogger(component)
 	logger.RegisterSink(sink)
 
@@ -74,6 +463,102 @@ func (c *LoadDataConfig) NewLogger(component string) lager.Logger {
 }
 
 func (c *LoadDataConfig) Validate() error {
+	// a run resumed without the seed of the first would plan a different dataset
+	if c.CheckpointPath != "" && c.TestDataConfig.Seed == 0 {
+		return errors.New("error in checkpoint_path: a seed is required to resume from a checkpoint")
+	}
+
+	if c.Concurrency < 0 {
+		return errors.New("error in concurrency: must not be negative")
+	}
+
+	a := c.AdaptiveConcurrencyConfig
+	if a.Min < 0 || a.Max < 0 || a.LatencyThreshold < 0 {
+		return errors.New("error in adaptive_concurrency: min, max and latency_threshold must not be negative")
+	}
+	if a.Max > 0 && a.Min > a.Max {
+		return errors.New("error in adaptive_concurrency: min must not be greater than max")
+	}
+
+	err := c.RateLimitConfig.validate()
+	if err != nil {
+		return fmt.Errorf("error in rate_limit: %s", err)
+	}
+
+	b := c.FailureBudgetConfig
+	if b.MaxFailures < 0 || b.MaxFailurePercent < 0 || b.MaxFailurePercent > 1 {
+		return errors.New("error in failure_budget: max_failures must not be negative and max_failure_percent must be between 0 and 1")
+	}
+
+	err = c.RetryConfig.validate()
+	if err != nil {
+		return fmt.Errorf("error in retry: %s", err)
+	}
+
+	if c.CloudControllerConfig.Timeout < 0 {
+		return errors.New("error in cloud_controller: timeout must not be negative")
+	}
+	switch c.CloudControllerConfig.APIVersion {
+	case "", APIVersionV2, APIVersionV3:
+	default:
+		return fmt.Errorf("error in cloud_controller: unknown api_version %q", c.CloudControllerConfig.APIVersion)
+	}
+
+	err = c.TestDataConfig.SpacesPerOrgDistribution.validate()
+	if err != nil {
+		return fmt.Errorf("error in spaces_per_org_distribution: %s", err)
+	}
+	err = c.TestDataConfig.AppsPerSpaceDistribution.validate()
+	if err != nil {
+		return fmt.Errorf("error in apps_per_space_distribution: %s", err)
+	}
+
+	external := c.TestDataConfig.ExternalEnvironmentConfig
+	switch external.OrgSelection {
+	case "", SelectionWindow, SelectionUniform, SelectionZipf:
+	default:
+		return fmt.Errorf("error in org_selection: unknown strategy %q", external.OrgSelection)
+	}
+	switch external.SpaceSelection {
+	case "", SelectionWindow, SelectionUniform, SelectionZipf, SelectionLocality:
+	default:
+		return fmt.Errorf("error in space_selection: unknown strategy %q", external.SpaceSelection)
+	}
+	if external.ZipfExponent < 0 {
+		return errors.New("error in zipf_exponent: must not be negative")
+	}
+
+	shape := c.TestDataConfig.AppShapeConfig
+	err = shape.validate()
+	if err != nil {
+		return fmt.Errorf("error in app_shape: %s", err)
+	}
+	if (shape.Instances > 0 || shape.MemoryInMB > 0) && c.CloudControllerConfig.APIVersion == APIVersionV3 {
+		return errors.New("error in app_shape: instances and memory_in_mb need api_version v2, apps created with v3 have no web process until they are staged")
+	}
+
+	switch c.Backend {
+	case "", BackendCloudController:
+	case BackendPerm:
+		if c.PermConfig.Address == "" || c.PermConfig.CACert == "" || c.PermConfig.ActorNamespace == "" {
+			return errors.New("error in perm: address, ca_cert and actor_namespace must be provided")
+		}
+		if c.PermConfig.Timeout < 0 {
+			return errors.New("error in perm: timeout must not be negative")
+		}
+	default:
+		return fmt.Errorf("error in backend: unknown backend %q", c.Backend)
+	}
+
+	if c.UAAConfig.Enabled() {
+		if c.UAAConfig.ClientSecret == "" || c.UAAConfig.UserPassword == "" {
+			return errors.New("error in uaa: client_secret and user_password must be provided")
+		}
+		if c.Backend == BackendPerm && c.UAAConfig.URL == "" {
+			return errors.New("error in uaa: url must be provided with the perm backend")
+		}
+	}
+
 	var p float64
 	for _, d := range c.TestDataConfig.ExternalEnvironmentConfig.UserOrgDistributions {
 		p += d.PercentUsers
@@ -86,11 +571,25 @@ func (c *LoadDataConfig) Validate() error {
 		return errors.New("error in user_org_distribution: percentage of users must sum to 1")
 	}
 
+	for _, d := range c.TestDataConfig.ExternalEnvironmentConfig.UserOrgDistributions {
+		err := validateRoleMix(d.RoleMix, IsOrgRole)
+		if err != nil {
+			return fmt.Errorf("error in user_org_distribution: %s", err)
+		}
+	}
+
+	// sizes sampled from a distribution depend on the seed, so without one this is only a guide
+	testSpaceCount := NewPlan(TestDataConfig{
+		SpacesPerOrgCount:        c.TestDataConfig.SpacesPerOrgCount,
+		SpacesPerOrgDistribution: c.TestDataConfig.SpacesPerOrgDistribution,
+		TestEnvironmentConfig:    c.TestDataConfig.TestEnvironmentConfig,
+	}, c.TestDataConfig.Seed).TestEnvironment.Summary().SpaceCount
+
 	p = 0.0
 	for _, d := range c.TestDataConfig.ExternalEnvironmentConfig.UserSpaceDistributions {
 		p += d.PercentUsers
 
-		if d.NumSpaces > (c.TestDataConfig.TestEnvironmentConfig.OrgCount * c.TestDataConfig.SpacesPerOrgCount) {
+		if d.NumSpaces > testSpaceCount {
 			return errors.New("error in user_space_distribution: users in external environment should not have access to more spaces than test user")
 		}
 	}
@@ -98,5 +597,69 @@ func (c *LoadDataConfig) Validate() error {
 		return errors.New("error in user_space_distribution: percentage of users must sum to 1")
 	}
 
+	for _, d := range c.TestDataConfig.ExternalEnvironmentConfig.UserSpaceDistributions {
+		err := validateRoleMix(d.RoleMix, IsSpaceRole)
+		if err != nil {
+			return fmt.Errorf("error in user_space_distribution: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func (c RateLimitConfig) validate() error {
+	if c.RequestsPerSecond < 0 || c.Burst < 0 {
+		return errors.New("requests_per_second and burst must not be negative")
+	}
+
+	for op, rate := range c.Operations {
+		if !contains(cf.Operations, op) {
+			return fmt.Errorf("unknown operation %q", op)
+		}
+		if rate <= 0 {
+			return fmt.Errorf("rate of %s must be positive", op)
+		}
+	}
+
+	return nil
+}
+
+func (c RetryConfig) validate() error {
+	if c.MaxElapsedTime < 0 || c.InitialInterval < 0 || c.MaxInterval < 0 {
+		return errors.New("max_elapsed_time, initial_interval and max_interval must not be negative")
+	}
+	if c.Multiplier != 0 && c.Multiplier < 1 {
+		return errors.New("multiplier must be at least 1")
+	}
+	if c.Jitter < 0 || c.Jitter > 1 {
+		return errors.New("jitter must be between 0 and 1")
+	}
+
+	for _, code := range c.RetryableStatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("%d is not an HTTP status code", code)
+		}
+	}
+
+	return nil
+}
+
+func validateRoleMix(mix []RoleWeight, valid func(string) bool) error {
+	if len(mix) == 0 {
+		return nil
+	}
+
+	var p float64
+	for _, w := range mix {
+		if !valid(w.Role) {
+			return fmt.Errorf("role %q is not allowed in role_mix", w.Role)
+		}
+		p += w.Percent
+	}
+
+	if math.Abs(p-1) > 1e-9 {
+		return errors.New("percentage of roles in role_mix must sum to 1")
+	}
+
 	return nil
 }
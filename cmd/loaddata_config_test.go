@@ -0,0 +1,146 @@
+package cmd_test
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/perm-test/cf"
+
+	. "github.com/pivotal-cf/perm-test/cmd"
+)
+
+var _ = Describe("CloudControllerConfig", func() {
+	Describe("NewTLSConfig", func() {
+		var (
+			server *httptest.Server
+			config CloudControllerConfig
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+			config = CloudControllerConfig{URL: server.URL}
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		get := func() error {
+			tlsConfig, err := config.NewTLSConfig()
+			Expect(err).NotTo(HaveOccurred())
+
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+			resp, err := client.Get(server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			return err
+		}
+
+		It("skips validation by default", func() {
+			Expect(config.SkipSSLValidation()).To(BeTrue())
+			Expect(get()).To(Succeed())
+		})
+
+		It("refuses untrusted certificates in strict mode", func() {
+			config.StrictTLS = true
+
+			Expect(config.SkipSSLValidation()).To(BeFalse())
+			Expect(get()).NotTo(Succeed())
+		})
+
+		It("trusts the CA cert", func() {
+			f, err := ioutil.TempFile("", "ca-cert")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.Remove(f.Name())
+
+			err = pem.Encode(f, &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+			Expect(err).NotTo(HaveOccurred())
+			f.Close()
+
+			config.CACert = f.Name()
+
+			Expect(config.SkipSSLValidation()).To(BeFalse())
+			Expect(get()).To(Succeed())
+		})
+
+		It("returns an error if the CA cert has no certificates", func() {
+			f, err := ioutil.TempFile("", "ca-cert")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.Remove(f.Name())
+			f.Close()
+
+			config.CACert = f.Name()
+
+			_, err = config.NewTLSConfig()
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("LoadDataConfig", func() {
+	Describe("Validate", func() {
+		var config LoadDataConfig
+
+		BeforeEach(func() {
+			config = LoadDataConfig{
+				TestDataConfig: TestDataConfig{
+					ExternalEnvironmentConfig: ExternalEnvironmentConfig{
+						UserOrgDistributions:   []UserOrgDistribution{{PercentUsers: 1}},
+						UserSpaceDistributions: []UserSpaceDistribution{{PercentUsers: 1}},
+					},
+				},
+			}
+		})
+
+		It("requires a seed with a checkpoint", func() {
+			config.CheckpointPath = "/tmp/checkpoint.jsonl"
+			Expect(config.Validate()).To(MatchError(ContainSubstring("a seed is required")))
+
+			config.TestDataConfig.Seed = 42
+			Expect(config.Validate()).To(Succeed())
+		})
+	})
+})
+
+var _ = Describe("FailureBudgetConfig", func() {
+	Describe("Allowed", func() {
+		It("tolerates no failures by default", func() {
+			Expect(FailureBudgetConfig{}.Allowed(1000)).To(Equal(0))
+		})
+
+		It("allows the larger of the count and the fraction of the total", func() {
+			config := FailureBudgetConfig{MaxFailures: 5, MaxFailurePercent: 0.01}
+
+			Expect(config.Allowed(100)).To(Equal(5))
+			Expect(config.Allowed(1050)).To(Equal(10))
+		})
+	})
+})
+
+var _ = Describe("RetryConfig", func() {
+	Describe("Policy", func() {
+		It("keeps the defaults for anything not configured", func() {
+			Expect(RetryConfig{}.Policy()).To(Equal(cf.DefaultRetryPolicy()))
+		})
+
+		It("overrides the defaults", func() {
+			policy := RetryConfig{
+				MaxElapsedTime:      time.Minute,
+				Jitter:              0.1,
+				RetryableErrorCodes: []int{10001},
+			}.Policy()
+
+			Expect(policy.MaxElapsedTime).To(Equal(time.Minute))
+			Expect(policy.RandomizationFactor).To(Equal(0.1))
+			Expect(policy.RetryableErrorCodes).To(Equal([]int{10001}))
+			Expect(policy.RetryableStatusCodes).To(Equal(cf.DefaultRetryPolicy().RetryableStatusCodes))
+		})
+	})
+})
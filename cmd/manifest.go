@@ -0,0 +1,313 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+const (
+	ManifestEntryOrg   = "org"
+	ManifestEntrySpace = "space"
+	ManifestEntryApp   = "app"
+	ManifestEntryUser  = "user"
+	ManifestEntryRole  = "role"
+)
+
+const (
+	RoleOrgUser           = "org_user"
+	RoleOrgManager        = "org_manager"
+	RoleOrgBillingManager = "org_billing_manager"
+	RoleOrgAuditor        = "org_auditor"
+
+	RoleSpaceDeveloper = "space_developer"
+	RoleSpaceManager   = "space_manager"
+	RoleSpaceAuditor   = "space_auditor"
+)
+
+var (
+	OrgRoles   = []string{RoleOrgUser, RoleOrgManager, RoleOrgBillingManager, RoleOrgAuditor}
+	SpaceRoles = []string{RoleSpaceDeveloper, RoleSpaceManager, RoleSpaceAuditor}
+)
+
+func IsOrgRole(role string) bool {
+	return contains(OrgRoles, role)
+}
+
+func IsSpaceRole(role string) bool {
+	return contains(SpaceRoles, role)
+}
+
+func contains(roles []string, role string) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ManifestEntry records a single resource created or role assigned while seeding
+//
+// For roles, GUID is the org or space the user was assigned to
+type ManifestEntry struct {
+	Type     string `json:"type"`
+	Name     string `json:"name,omitempty"`
+	GUID     string `json:"guid,omitempty"`
+	Role     string `json:"role,omitempty"`
+	UserGUID string `json:"user_guid,omitempty"`
+}
+
+type roleKey struct {
+	role       string
+	userGUID   string
+	targetGUID string
+}
+
+// Manifest is a checkpoint of the work done by a seeding run
+//
+// It is persisted as newline-delimited JSON entries, appended as each
+// piece of work completes, so that a crashed run loses at most the
+// operations which were in flight.
+type Manifest struct {
+	mu sync.RWMutex
+	w  io.Writer
+
+	entries []ManifestEntry
+	orgs    map[string]string
+	spaces  map[string]string
+	apps    map[string]string
+	users   map[string]string
+	roles   map[roleKey]struct{}
+}
+
+// NewManifest returns an empty manifest which records entries to w
+//
+// w may be nil, in which case entries are only kept in memory
+func NewManifest(w io.Writer) *Manifest {
+	return &Manifest{
+		w:      w,
+		orgs:   make(map[string]string),
+		spaces: make(map[string]string),
+		apps:   make(map[string]string),
+		users:  make(map[string]string),
+		roles:  make(map[roleKey]struct{}),
+	}
+}
+
+// ReadManifest parses the entries of a persisted manifest
+//
+// A truncated final line, left behind if the writer was killed mid-write, is ignored
+func ReadManifest(r io.Reader) (*Manifest, error) {
+	m := NewManifest(nil)
+
+	scanner := bufio.NewScanner(r)
+	var malformed error
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		if malformed != nil {
+			return nil, malformed
+		}
+
+		var entry ManifestEntry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			malformed = fmt.Errorf("malformed manifest entry on line %d: %s", line, err)
+			continue
+		}
+
+		m.index(entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// OpenManifest reads the manifest at path, if it exists, and appends any further entries to it
+//
+// A truncated final line is removed from the file before appending
+func OpenManifest(path string) (*Manifest, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := ioutil.ReadAll(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	complete := bytes.LastIndexByte(contents, '\n') + 1
+	m, err := ReadManifest(bytes.NewReader(contents[:complete]))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	err = f.Truncate(int64(complete))
+	if err == nil {
+		_, err = f.Seek(int64(complete), io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	m.w = f
+	return m, nil
+}
+
+// LoadManifest reads the manifest at path without opening it for writing
+func LoadManifest(path string) (*Manifest, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadManifest(f)
+}
+
+func (m *Manifest) Org(name string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	guid, ok := m.orgs[name]
+	return guid, ok
+}
+
+func (m *Manifest) Space(name string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	guid, ok := m.spaces[name]
+	return guid, ok
+}
+
+func (m *Manifest) App(name string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	guid, ok := m.apps[name]
+	return guid, ok
+}
+
+func (m *Manifest) User(name string) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	guid, ok := m.users[name]
+	return guid, ok
+}
+
+func (m *Manifest) HasRole(role string, userGUID string, targetGUID string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.roles[roleKey{role, userGUID, targetGUID}]
+	return ok
+}
+
+func (m *Manifest) RecordOrg(name string, guid string) error {
+	return m.record(ManifestEntry{Type: ManifestEntryOrg, Name: name, GUID: guid})
+}
+
+func (m *Manifest) RecordSpace(name string, guid string) error {
+	return m.record(ManifestEntry{Type: ManifestEntrySpace, Name: name, GUID: guid})
+}
+
+func (m *Manifest) RecordApp(name string, guid string) error {
+	return m.record(ManifestEntry{Type: ManifestEntryApp, Name: name, GUID: guid})
+}
+
+func (m *Manifest) RecordUser(name string, guid string) error {
+	return m.record(ManifestEntry{Type: ManifestEntryUser, Name: name, GUID: guid})
+}
+
+func (m *Manifest) RecordRole(role string, userGUID string, targetGUID string) error {
+	return m.record(ManifestEntry{Type: ManifestEntryRole, Role: role, UserGUID: userGUID, GUID: targetGUID})
+}
+
+// Entries returns every entry in the manifest, in the order they were recorded
+func (m *Manifest) Entries() []ManifestEntry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entries := make([]ManifestEntry, len(m.entries))
+	copy(entries, m.entries)
+	return entries
+}
+
+// Counts returns the number of entries in the manifest of each type
+func (m *Manifest) Counts() map[string]int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, e := range m.entries {
+		counts[e.Type]++
+	}
+	return counts
+}
+
+// Close closes the underlying writer, if it can be closed
+func (m *Manifest) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, ok := m.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (m *Manifest) record(entry ManifestEntry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.w != nil {
+		b, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+
+		_, err = m.w.Write(append(b, '\n'))
+		if err != nil {
+			return err
+		}
+	}
+
+	m.index(entry)
+	return nil
+}
+
+func (m *Manifest) index(entry ManifestEntry) {
+	m.entries = append(m.entries, entry)
+
+	switch entry.Type {
+	case ManifestEntryOrg:
+		m.orgs[entry.Name] = entry.GUID
+	case ManifestEntrySpace:
+		m.spaces[entry.Name] = entry.GUID
+	case ManifestEntryApp:
+		m.apps[entry.Name] = entry.GUID
+	case ManifestEntryUser:
+		m.users[entry.Name] = entry.GUID
+	case ManifestEntryRole:
+		m.roles[roleKey{entry.Role, entry.UserGUID, entry.GUID}] = struct{}{}
+	}
+}
@@ -0,0 +1,114 @@
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/pivotal-cf/perm-test/cmd"
+)
+
+var _ = Describe("Manifest", func() {
+	Describe("Record*", func() {
+		It("indexes the entries and writes them as newline-delimited JSON", func() {
+			buf := bytes.NewBuffer(nil)
+			m := NewManifest(buf)
+
+			Expect(m.RecordOrg("org-0", "org-guid")).To(Succeed())
+			Expect(m.RecordSpace("space-0", "space-guid")).To(Succeed())
+			Expect(m.RecordUser("user-0", "user-guid")).To(Succeed())
+			Expect(m.RecordRole(RoleSpaceDeveloper, "user-guid", "space-guid")).To(Succeed())
+
+			guid, ok := m.Org("org-0")
+			Expect(ok).To(BeTrue())
+			Expect(guid).To(Equal("org-guid"))
+
+			_, ok = m.Org("org-1")
+			Expect(ok).To(BeFalse())
+
+			Expect(m.HasRole(RoleSpaceDeveloper, "user-guid", "space-guid")).To(BeTrue())
+			Expect(m.HasRole(RoleOrgUser, "user-guid", "space-guid")).To(BeFalse())
+
+			Expect(buf.String()).To(Equal(`{"type":"org","name":"org-0","guid":"org-guid"}
+{"type":"space","name":"space-0","guid":"space-guid"}
+{"type":"user","name":"user-0","guid":"user-guid"}
+{"type":"role","guid":"space-guid","role":"space_developer","user_guid":"user-guid"}
+`))
+		})
+	})
+
+	Describe("Counts", func() {
+		It("counts the entries of each type", func() {
+			m := NewManifest(nil)
+
+			Expect(m.RecordOrg("org-0", "org-guid")).To(Succeed())
+			Expect(m.RecordOrg("org-1", "other-org-guid")).To(Succeed())
+			Expect(m.RecordRole(RoleOrgUser, "user-guid", "org-guid")).To(Succeed())
+
+			Expect(m.Counts()).To(Equal(map[string]int{
+				ManifestEntryOrg:  2,
+				ManifestEntryRole: 1,
+			}))
+		})
+	})
+
+	Describe("ReadManifest", func() {
+		It("ignores a truncated final line", func() {
+			m, err := ReadManifest(bytes.NewBufferString(`{"type":"org","name":"org-0","guid":"org-guid"}
+{"type":"space","na`))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(m.Entries()).To(HaveLen(1))
+			_, ok := m.Space("space-0")
+			Expect(ok).To(BeFalse())
+		})
+
+		It("errors on a malformed line before the end", func() {
+			_, err := ReadManifest(bytes.NewBufferString(`{"type":"org","na
+{"type":"org","name":"org-0","guid":"org-guid"}
+`))
+			Expect(err).To(MatchError(ContainSubstring("line 1")))
+		})
+	})
+
+	Describe("OpenManifest", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "manifest")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("resumes from the existing entries and drops any truncated final line", func() {
+			path := filepath.Join(dir, "checkpoint.jsonl")
+			err := ioutil.WriteFile(path, []byte(`{"type":"org","name":"org-0","guid":"org-guid"}
+{"type":"sp`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+
+			m, err := OpenManifest(path)
+			Expect(err).NotTo(HaveOccurred())
+
+			_, ok := m.Org("org-0")
+			Expect(ok).To(BeTrue())
+
+			Expect(m.RecordOrg("org-1", "other-org-guid")).To(Succeed())
+			Expect(m.Close()).To(Succeed())
+
+			m, err = LoadManifest(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Entries()).To(Equal([]ManifestEntry{
+				{Type: ManifestEntryOrg, Name: "org-0", GUID: "org-guid"},
+				{Type: ManifestEntryOrg, Name: "org-1", GUID: "other-org-guid"},
+			}))
+		})
+	})
+})
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Bucket identifies the user org distribution and user space distribution an
+// external user was sampled from, e.g. the 1% of users with access to 500 orgs
+//
+// The distributions are identified by their index in the config, or -1 if the
+// percentages did not cover the user's sample.
+type Bucket struct {
+	OrgDistribution   int `json:"org_distribution"`
+	SpaceDistribution int `json:"space_distribution"`
+	NumOrgs           int `json:"num_orgs"`
+	NumSpaces         int `json:"num_spaces"`
+}
+
+func (b Bucket) String() string {
+	return fmt.Sprintf("orgs[%d]=%d/spaces[%d]=%d", b.OrgDistribution, b.NumOrgs, b.SpaceDistribution, b.NumSpaces)
+}
+
+func (b Bucket) orgDistribution(distributions []UserOrgDistribution) UserOrgDistribution {
+	if b.OrgDistribution < 0 {
+		return UserOrgDistribution{}
+	}
+	return distributions[b.OrgDistribution]
+}
+
+func (b Bucket) spaceDistribution(distributions []UserSpaceDistribution) UserSpaceDistribution {
+	if b.SpaceDistribution < 0 {
+		return UserSpaceDistribution{}
+	}
+	return distributions[b.SpaceDistribution]
+}
+
+// Personas are generated users sampled from a bucket, whom experiments log in as
+type Personas struct {
+	Bucket Bucket
+	Users  []UserPlan
+}
+
+// Personas returns up to n users from every bucket with at least one user,
+// ordered by org and then space distribution
+//
+// The first users of each bucket in the plan are chosen, so the same plan
+// always produces the same personas.
+func (e EnvironmentPlan) Personas(n int) []Personas {
+	byBucket := make(map[Bucket][]UserPlan)
+	for _, u := range e.Users {
+		if len(byBucket[u.Bucket]) < n {
+			byBucket[u.Bucket] = append(byBucket[u.Bucket], u)
+		}
+	}
+
+	var personas []Personas
+	for b, users := range byBucket {
+		personas = append(personas, Personas{Bucket: b, Users: users})
+	}
+
+	sort.Slice(personas, func(i, j int) bool {
+		a, b := personas[i].Bucket, personas[j].Bucket
+		if a.OrgDistribution != b.OrgDistribution {
+			return a.OrgDistribution < b.OrgDistribution
+		}
+		return a.SpaceDistribution < b.SpaceDistribution
+	})
+
+	return personas
+}
@@ -0,0 +1,69 @@
+package cmd_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/pivotal-cf/perm-test/cmd"
+)
+
+var _ = Describe("Personas", func() {
+	var plan *Plan
+
+	BeforeEach(func() {
+		plan = NewPlan(TestDataConfig{
+			SpacesPerOrgCount: 2,
+			TestEnvironmentConfig: TestEnvironmentConfig{
+				OrgCount: 10,
+			},
+			ExternalEnvironmentConfig: ExternalEnvironmentConfig{
+				OrgCount:  10,
+				UserCount: 50,
+				UserOrgDistributions: []UserOrgDistribution{
+					{PercentUsers: 0.2, NumOrgs: 5},
+					{PercentUsers: 0.8, NumOrgs: 1},
+				},
+				UserSpaceDistributions: []UserSpaceDistribution{
+					{PercentUsers: 1, NumSpaces: 2},
+				},
+			},
+		}, 42)
+	})
+
+	It("records the bucket each external user was sampled from", func() {
+		for _, u := range plan.ExternalEnvironment.Users {
+			Expect(u.Orgs).To(HaveLen(u.Bucket.NumOrgs))
+			Expect(u.Spaces).To(HaveLen(u.Bucket.NumSpaces))
+			Expect(u.Bucket.SpaceDistribution).To(Equal(0))
+
+			switch u.Bucket.OrgDistribution {
+			case 0:
+				Expect(u.Bucket.NumOrgs).To(Equal(5))
+			case 1:
+				Expect(u.Bucket.NumOrgs).To(Equal(1))
+			default:
+				Fail("unexpected org distribution")
+			}
+		}
+	})
+
+	It("returns the first users of each bucket, ordered by distribution", func() {
+		personas := plan.ExternalEnvironment.Personas(3)
+
+		Expect(personas).To(HaveLen(2))
+		Expect(personas[0].Bucket.String()).To(Equal("orgs[0]=5/spaces[0]=2"))
+		Expect(personas[1].Bucket.String()).To(Equal("orgs[1]=1/spaces[0]=2"))
+
+		for _, p := range personas {
+			Expect(p.Users).To(HaveLen(3))
+
+			var inBucket []string
+			for _, u := range plan.ExternalEnvironment.Users {
+				if u.Bucket == p.Bucket {
+					inBucket = append(inBucket, u.Name)
+				}
+			}
+			Expect([]string{p.Users[0].Name, p.Users[1].Name, p.Users[2].Name}).To(Equal(inBucket[:3]))
+		}
+	})
+})
@@ -0,0 +1,436 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// Every org, space, app and user in the plan is named with one of these prefixes,
+// which is how teardown recognises the seeded dataset
+const (
+	TestEnvironmentPrefix     = "perm-test"
+	ExternalEnvironmentPrefix = "perm-external"
+)
+
+// Plan is the complete dataset seeded by loaddata
+//
+// It is generated from the config without talking to Cloud Controller,
+// so the same config and seed always produce the same plan.
+type Plan struct {
+	Seed                int64           `json:"seed"`
+	TestEnvironment     EnvironmentPlan `json:"test_environment"`
+	ExternalEnvironment EnvironmentPlan `json:"external_environment"`
+}
+
+type EnvironmentPlan struct {
+	Orgs  []OrgPlan  `json:"orgs"`
+	Users []UserPlan `json:"users"`
+}
+
+type OrgPlan struct {
+	Name   string      `json:"name"`
+	Spaces []SpacePlan `json:"spaces"`
+}
+
+type SpacePlan struct {
+	Name    string   `json:"name"`
+	OrgName string   `json:"org_name"`
+	Apps    []string `json:"apps"`
+}
+
+// UserPlan is a user and the orgs and spaces they are assigned to
+//
+// A user assigned to a space is also a member of the space's org.
+// OrgRoles and SpaceRoles map org and space names to the role the user is
+// given there, which defaults to org_user and space_developer.
+// Bucket is the pair of distributions the user was sampled from.
+type UserPlan struct {
+	Name       string            `json:"name"`
+	GUID       string            `json:"guid"`
+	Bucket     Bucket            `json:"-"`
+	Orgs       []OrgPlan         `json:"-"`
+	Spaces     []SpacePlan       `json:"-"`
+	OrgRoles   map[string]string `json:"-"`
+	SpaceRoles map[string]string `json:"-"`
+}
+
+type RoleAssignment struct {
+	UserGUID  string `json:"user_guid"`
+	Role      string `json:"role"`
+	OrgName   string `json:"org_name"`
+	SpaceName string `json:"space_name,omitempty"`
+}
+
+type PlanSummary struct {
+	OrgCount               int            `json:"org_count"`
+	SpaceCount             int            `json:"space_count"`
+	AppCount               int            `json:"app_count"`
+	UserCount              int            `json:"user_count"`
+	RoleAssignmentCount    int            `json:"role_assignment_count"`
+	RoleCounts             map[string]int `json:"role_counts"`
+	OrgAssociationsSaved   int            `json:"org_associations_saved"`
+	OrgsPerUserHistogram   map[int]int    `json:"orgs_per_user_histogram"`
+	SpacesPerUserHistogram map[int]int    `json:"spaces_per_user_histogram"`
+	SpacesPerOrgHistogram  map[int]int    `json:"spaces_per_org_histogram"`
+	AppsPerSpaceHistogram  map[int]int    `json:"apps_per_space_histogram"`
+}
+
+// NewPlan generates the test and external environments described by the config
+func NewPlan(c TestDataConfig, seed int64) *Plan {
+	r := rand.New(rand.NewSource(seed))
+
+	testOrgs := planOrgs(TestEnvironmentPrefix, c.TestEnvironmentConfig.OrgCount, c, seed)
+	externalOrgs := planOrgs(ExternalEnvironmentPrefix, c.ExternalEnvironmentConfig.OrgCount, c, seed)
+
+	var externalSpaces []SpacePlan
+	for _, org := range externalOrgs {
+		externalSpaces = append(externalSpaces, org.Spaces...)
+	}
+
+	// For every user
+	//  Calculate the number of orgs it should see
+	//    Randomly assign an org role for that many orgs
+	//  Calculate the number of spaces it should see
+	//    Randomly assign a space role for that many spaces
+	external := c.ExternalEnvironmentConfig
+	var externalUsers []UserPlan
+	for i := 0; i < external.UserCount; i++ {
+		guid := RandomUUID(r).String()
+
+		bucket := Bucket{
+			OrgDistribution: ChooseOrgDistributionIndex(r, c.ExternalEnvironmentConfig.UserOrgDistributions),
+		}
+		orgDistribution := bucket.orgDistribution(c.ExternalEnvironmentConfig.UserOrgDistributions)
+		orgs := ChooseOrgs(r, external.OrgSelection, external.zipfExponent(), externalOrgs, uint(orgDistribution.NumOrgs))
+
+		bucket.SpaceDistribution = ChooseSpaceDistributionIndex(r, c.ExternalEnvironmentConfig.UserSpaceDistributions)
+		spaceDistribution := bucket.spaceDistribution(c.ExternalEnvironmentConfig.UserSpaceDistributions)
+		spaces := ChooseSpaces(r, external.SpaceSelection, external.zipfExponent(), externalSpaces, orgs, uint(spaceDistribution.NumSpaces))
+
+		bucket.NumOrgs = orgDistribution.NumOrgs
+		bucket.NumSpaces = spaceDistribution.NumSpaces
+
+		orgRoles := make(map[string]string)
+		for _, org := range orgs {
+			orgRoles[org.Name] = ChooseRole(r, orgDistribution.RoleMix, RoleOrgUser)
+		}
+
+		spaceRoles := make(map[string]string)
+		for _, space := range spaces {
+			spaceRoles[space.Name] = ChooseRole(r, spaceDistribution.RoleMix, RoleSpaceDeveloper)
+		}
+
+		externalUsers = append(externalUsers, UserPlan{
+			Name:       fmt.Sprintf("%s-user-%d", ExternalEnvironmentPrefix, i),
+			GUID:       guid,
+			Bucket:     bucket,
+			Orgs:       orgs,
+			Spaces:     spaces,
+			OrgRoles:   orgRoles,
+			SpaceRoles: spaceRoles,
+		})
+	}
+
+	var testSpaces []SpacePlan
+	for _, org := range testOrgs {
+		testSpaces = append(testSpaces, org.Spaces...)
+	}
+
+	return &Plan{
+		Seed: seed,
+		TestEnvironment: EnvironmentPlan{
+			Orgs: testOrgs,
+			Users: []UserPlan{
+				{
+					Name:   TestEnvironmentPrefix + "-user",
+					GUID:   c.TestEnvironmentConfig.UserGUID,
+					Orgs:   testOrgs,
+					Spaces: testSpaces,
+				},
+			},
+		},
+		ExternalEnvironment: EnvironmentPlan{
+			Orgs:  externalOrgs,
+			Users: externalUsers,
+		},
+	}
+}
+
+// IsSeededName returns true if name follows the naming scheme of the plan
+func IsSeededName(name string) bool {
+	return strings.HasPrefix(name, TestEnvironmentPrefix+"-") || strings.HasPrefix(name, ExternalEnvironmentPrefix+"-")
+}
+
+// planOrgs plans the orgs of an environment, sized by the config's distributions if it has any
+//
+// Sizes are sampled from a source seeded by both the plan's seed and the prefix, so that one seed
+// reproduces the whole dataset and the environments' sizes do not depend on the order they are planned in.
+func planOrgs(prefix string, orgCount int, c TestDataConfig, seed int64) []OrgPlan {
+	h := fnv.New64a()
+	h.Write([]byte(prefix))
+	r := rand.New(rand.NewSource(seed ^ int64(h.Sum64())))
+
+	spacesPerOrg := SizeSampler(r, c.SpacesPerOrgDistribution, c.SpacesPerOrgCount)
+	appsPerSpace := SizeSampler(r, c.AppsPerSpaceDistribution, c.AppsPerSpaceCount)
+
+	orgs := make([]OrgPlan, orgCount)
+	for i := range orgs {
+		orgName := fmt.Sprintf("%s-org-%d", prefix, i)
+
+		spaces := make([]SpacePlan, spacesPerOrg())
+		for j := range spaces {
+			apps := make([]string, appsPerSpace())
+			for k := range apps {
+				apps[k] = fmt.Sprintf("%s-app-%d-in-space-%d-in-org-%d", prefix, k, j, i)
+			}
+
+			spaces[j] = SpacePlan{
+				Name:    fmt.Sprintf("%s-space-%d-in-org-%d", prefix, j, i),
+				OrgName: orgName,
+				Apps:    apps,
+			}
+		}
+
+		orgs[i] = OrgPlan{
+			Name:   orgName,
+			Spaces: spaces,
+		}
+	}
+
+	return orgs
+}
+
+// RoleAssignments returns the distinct roles held by the user
+//
+// Users assigned to a space, or given any other org role, are also made users of the org
+func (u UserPlan) RoleAssignments() []RoleAssignment {
+	var assignments []RoleAssignment
+	seen := make(map[RoleAssignment]bool)
+
+	add := func(a RoleAssignment) {
+		if !seen[a] {
+			seen[a] = true
+			assignments = append(assignments, a)
+		}
+	}
+
+	for _, space := range u.Spaces {
+		add(RoleAssignment{UserGUID: u.GUID, Role: RoleOrgUser, OrgName: space.OrgName})
+		add(RoleAssignment{UserGUID: u.GUID, Role: u.SpaceRole(space.Name), OrgName: space.OrgName, SpaceName: space.Name})
+	}
+
+	for _, org := range u.Orgs {
+		add(RoleAssignment{UserGUID: u.GUID, Role: RoleOrgUser, OrgName: org.Name})
+		add(RoleAssignment{UserGUID: u.GUID, Role: u.OrgRole(org.Name), OrgName: org.Name})
+	}
+
+	return assignments
+}
+
+// OrgMemberships returns the distinct orgs the user must be a member of,
+// those containing its spaces followed by the rest of its orgs
+func (u UserPlan) OrgMemberships() []string {
+	var orgNames []string
+	seen := make(map[string]bool)
+
+	add := func(orgName string) {
+		if !seen[orgName] {
+			seen[orgName] = true
+			orgNames = append(orgNames, orgName)
+		}
+	}
+
+	for _, space := range u.Spaces {
+		add(space.OrgName)
+	}
+	for _, org := range u.Orgs {
+		add(org.Name)
+	}
+
+	return orgNames
+}
+
+// OrgRole returns the role the user is given in the org
+func (u UserPlan) OrgRole(orgName string) string {
+	if role, ok := u.OrgRoles[orgName]; ok {
+		return role
+	}
+	return RoleOrgUser
+}
+
+// SpaceRole returns the role the user is given in the space
+func (u UserPlan) SpaceRole(spaceName string) string {
+	if role, ok := u.SpaceRoles[spaceName]; ok {
+		return role
+	}
+	return RoleSpaceDeveloper
+}
+
+// Summary returns the totals of the environment, histograms of how many
+// orgs and spaces each user can see, and histograms of the sizes of the orgs and spaces
+//
+// OrgAssociationsSaved is how many redundant org associations seeding avoids
+// by associating each user with each of its orgs once.
+func (e EnvironmentPlan) Summary() PlanSummary {
+	s := PlanSummary{
+		OrgCount:               len(e.Orgs),
+		UserCount:              len(e.Users),
+		RoleCounts:             make(map[string]int),
+		OrgsPerUserHistogram:   make(map[int]int),
+		SpacesPerUserHistogram: make(map[int]int),
+		SpacesPerOrgHistogram:  make(map[int]int),
+		AppsPerSpaceHistogram:  make(map[int]int),
+	}
+
+	for _, org := range e.Orgs {
+		s.SpaceCount += len(org.Spaces)
+		s.SpacesPerOrgHistogram[len(org.Spaces)]++
+		for _, space := range org.Spaces {
+			s.AppCount += len(space.Apps)
+			s.AppsPerSpaceHistogram[len(space.Apps)]++
+		}
+	}
+
+	for _, u := range e.Users {
+		assignments := u.RoleAssignments()
+		s.RoleAssignmentCount += len(assignments)
+
+		// associating the user with the org of every space and every org, rather than each org once
+		s.OrgAssociationsSaved += len(u.Spaces) + len(u.Orgs) - len(u.OrgMemberships())
+
+		var orgs, spaces int
+		for _, a := range assignments {
+			s.RoleCounts[a.Role]++
+
+			switch {
+			case a.Role == RoleOrgUser:
+				orgs++
+			case IsSpaceRole(a.Role):
+				spaces++
+			}
+		}
+
+		s.OrgsPerUserHistogram[orgs]++
+		s.SpacesPerUserHistogram[spaces]++
+	}
+
+	return s
+}
+
+type planDocument struct {
+	Seed                int64               `json:"seed"`
+	TestEnvironment     environmentDocument `json:"test_environment"`
+	ExternalEnvironment environmentDocument `json:"external_environment"`
+}
+
+type environmentDocument struct {
+	Summary         PlanSummary      `json:"summary"`
+	Orgs            []OrgPlan        `json:"orgs"`
+	Users           []UserPlan       `json:"users"`
+	RoleAssignments []RoleAssignment `json:"role_assignments"`
+}
+
+func (e EnvironmentPlan) document() environmentDocument {
+	d := environmentDocument{
+		Summary:         e.Summary(),
+		Orgs:            e.Orgs,
+		Users:           e.Users,
+		RoleAssignments: []RoleAssignment{},
+	}
+
+	for _, u := range e.Users {
+		d.RoleAssignments = append(d.RoleAssignments, u.RoleAssignments()...)
+	}
+
+	return d
+}
+
+// WriteJSON writes the plan, its summary and every role assignment as a single JSON document
+func (p *Plan) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(planDocument{
+		Seed:                p.Seed,
+		TestEnvironment:     p.TestEnvironment.document(),
+		ExternalEnvironment: p.ExternalEnvironment.document(),
+	})
+}
+
+// WriteCSV writes a row for every org, space, app, user and role assignment in the plan
+//
+// The columns are: environment, kind, name, org, space, user_guid, role
+func (p *Plan) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write([]string{"environment", "kind", "name", "org", "space", "user_guid", "role"})
+	if err != nil {
+		return err
+	}
+
+	for _, env := range []struct {
+		name string
+		plan EnvironmentPlan
+	}{
+		{"test", p.TestEnvironment},
+		{"external", p.ExternalEnvironment},
+	} {
+		var rows [][]string
+		for _, org := range env.plan.Orgs {
+			rows = append(rows, []string{env.name, "org", org.Name, "", "", "", ""})
+
+			for _, space := range org.Spaces {
+				rows = append(rows, []string{env.name, "space", space.Name, org.Name, "", "", ""})
+
+				for _, app := range space.Apps {
+					rows = append(rows, []string{env.name, "app", app, org.Name, space.Name, "", ""})
+				}
+			}
+		}
+
+		for _, u := range env.plan.Users {
+			rows = append(rows, []string{env.name, "user", u.Name, "", "", u.GUID, ""})
+
+			for _, a := range u.RoleAssignments() {
+				rows = append(rows, []string{env.name, "role", "", a.OrgName, a.SpaceName, a.UserGUID, a.Role})
+			}
+		}
+
+		err = cw.WriteAll(rows)
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteHistogram writes a histogram as "bucket: count" lines in ascending bucket order
+func WriteHistogram(w io.Writer, title string, histogram map[int]int) error {
+	var buckets []int
+	for b := range histogram {
+		buckets = append(buckets, b)
+	}
+	sort.Ints(buckets)
+
+	_, err := fmt.Fprintf(w, "%s\n", title)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range buckets {
+		_, err = fmt.Fprintf(w, "  %6d: %d\n", b, histogram[b])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,221 @@
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/csv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/pivotal-cf/perm-test/cmd"
+)
+
+var _ = Describe("Plan", func() {
+	var config TestDataConfig
+
+	BeforeEach(func() {
+		config = TestDataConfig{
+			SpacesPerOrgCount: 2,
+			AppsPerSpaceCount: 3,
+			TestEnvironmentConfig: TestEnvironmentConfig{
+				UserGUID: "test-user-guid",
+				OrgCount: 4,
+			},
+			ExternalEnvironmentConfig: ExternalEnvironmentConfig{
+				OrgCount:  10,
+				UserCount: 20,
+				UserOrgDistributions: []UserOrgDistribution{
+					{PercentUsers: 0.5, NumOrgs: 3},
+					{PercentUsers: 0.5, NumOrgs: 1},
+				},
+				UserSpaceDistributions: []UserSpaceDistribution{
+					{PercentUsers: 0.5, NumSpaces: 4},
+					{PercentUsers: 0.5, NumSpaces: 1},
+				},
+			},
+		}
+	})
+
+	Describe("NewPlan", func() {
+		It("plans every org, space and app in both environments", func() {
+			p := NewPlan(config, 42)
+
+			Expect(p.TestEnvironment.Orgs).To(HaveLen(4))
+			Expect(p.TestEnvironment.Orgs[1].Name).To(Equal("perm-test-org-1"))
+			Expect(p.TestEnvironment.Orgs[1].Spaces[1].Name).To(Equal("perm-test-space-1-in-org-1"))
+			Expect(p.TestEnvironment.Orgs[1].Spaces[1].Apps).To(ConsistOf(
+				"perm-test-app-0-in-space-1-in-org-1",
+				"perm-test-app-1-in-space-1-in-org-1",
+				"perm-test-app-2-in-space-1-in-org-1",
+			))
+
+			Expect(p.ExternalEnvironment.Orgs).To(HaveLen(10))
+			Expect(p.ExternalEnvironment.Users).To(HaveLen(20))
+
+			summary := p.ExternalEnvironment.Summary()
+			Expect(summary.SpaceCount).To(Equal(20))
+			Expect(summary.AppCount).To(Equal(60))
+		})
+
+		It("gives the test user access to every org and space in the test environment", func() {
+			p := NewPlan(config, 42)
+
+			Expect(p.TestEnvironment.Users).To(HaveLen(1))
+
+			user := p.TestEnvironment.Users[0]
+			Expect(user.GUID).To(Equal("test-user-guid"))
+
+			summary := p.TestEnvironment.Summary()
+			Expect(summary.OrgsPerUserHistogram).To(Equal(map[int]int{4: 1}))
+			Expect(summary.SpacesPerUserHistogram).To(Equal(map[int]int{8: 1}))
+		})
+
+		It("sizes orgs and spaces from the distributions, reproducibly from the seed", func() {
+			config.ExternalEnvironmentConfig.OrgCount = 50
+			config.SpacesPerOrgDistribution = SizeDistribution{
+				Buckets: []SizeBucket{
+					{Percent: 0.2, Count: 20},
+					{Percent: 0.8, Count: 1},
+				},
+			}
+			config.AppsPerSpaceDistribution = SizeDistribution{
+				LogNormal: &LogNormalDistribution{Mu: 1, Sigma: 1},
+			}
+
+			p := NewPlan(config, 42)
+
+			sizes := make(map[int]bool)
+			for _, org := range p.ExternalEnvironment.Orgs {
+				sizes[len(org.Spaces)] = true
+			}
+			Expect(sizes).To(Equal(map[int]bool{1: true, 20: true}))
+
+			apps := make(map[int]bool)
+			for _, org := range p.ExternalEnvironment.Orgs {
+				for _, space := range org.Spaces {
+					apps[len(space.Apps)] = true
+				}
+			}
+			Expect(len(apps)).To(BeNumerically(">", 1))
+
+			Expect(NewPlan(config, 42).ExternalEnvironment.Orgs).To(Equal(p.ExternalEnvironment.Orgs))
+			Expect(NewPlan(config, 43).ExternalEnvironment.Orgs).NotTo(Equal(p.ExternalEnvironment.Orgs))
+		})
+
+		It("chooses each user's spaces from its orgs with locality selection", func() {
+			config.ExternalEnvironmentConfig.OrgSelection = SelectionZipf
+			config.ExternalEnvironmentConfig.SpaceSelection = SelectionLocality
+
+			for _, user := range NewPlan(config, 42).ExternalEnvironment.Users {
+				orgs := make(map[string]bool)
+				for _, org := range user.Orgs {
+					orgs[org.Name] = true
+				}
+
+				for _, space := range user.Spaces {
+					if len(user.Spaces) <= 2*len(user.Orgs) {
+						Expect(orgs).To(HaveKey(space.OrgName))
+					}
+				}
+			}
+		})
+
+		It("produces the same plan from the same seed", func() {
+			a := bytes.NewBuffer(nil)
+			Expect(NewPlan(config, 42).WriteJSON(a)).To(Succeed())
+
+			b := bytes.NewBuffer(nil)
+			Expect(NewPlan(config, 42).WriteJSON(b)).To(Succeed())
+
+			Expect(a.String()).To(Equal(b.String()))
+
+			c := bytes.NewBuffer(nil)
+			Expect(NewPlan(config, 43).WriteJSON(c)).To(Succeed())
+
+			Expect(a.String()).NotTo(Equal(c.String()))
+		})
+	})
+
+	Describe("IsSeededName", func() {
+		It("matches only names in the plan's naming scheme", func() {
+			Expect(IsSeededName("perm-test-org-1")).To(BeTrue())
+			Expect(IsSeededName("perm-external-space-0-in-org-3")).To(BeTrue())
+
+			Expect(IsSeededName("perm-testing")).To(BeFalse())
+			Expect(IsSeededName("system")).To(BeFalse())
+			Expect(IsSeededName("")).To(BeFalse())
+		})
+	})
+
+	Describe("UserPlan.RoleAssignments", func() {
+		It("includes the orgs of the user's spaces and removes duplicates", func() {
+			space := SpacePlan{Name: "space-0", OrgName: "org-0"}
+			user := UserPlan{
+				GUID:   "user-guid",
+				Orgs:   []OrgPlan{{Name: "org-0"}, {Name: "org-1"}},
+				Spaces: []SpacePlan{space},
+			}
+
+			Expect(user.RoleAssignments()).To(Equal([]RoleAssignment{
+				{UserGUID: "user-guid", Role: RoleOrgUser, OrgName: "org-0"},
+				{UserGUID: "user-guid", Role: RoleSpaceDeveloper, OrgName: "org-0", SpaceName: "space-0"},
+				{UserGUID: "user-guid", Role: RoleOrgUser, OrgName: "org-1"},
+			}))
+		})
+
+		It("uses the user's planned org and space roles", func() {
+			user := UserPlan{
+				GUID:       "user-guid",
+				Orgs:       []OrgPlan{{Name: "org-1"}},
+				Spaces:     []SpacePlan{{Name: "space-0", OrgName: "org-0"}},
+				OrgRoles:   map[string]string{"org-1": RoleOrgBillingManager},
+				SpaceRoles: map[string]string{"space-0": RoleSpaceAuditor},
+			}
+
+			Expect(user.RoleAssignments()).To(Equal([]RoleAssignment{
+				{UserGUID: "user-guid", Role: RoleOrgUser, OrgName: "org-0"},
+				{UserGUID: "user-guid", Role: RoleSpaceAuditor, OrgName: "org-0", SpaceName: "space-0"},
+				{UserGUID: "user-guid", Role: RoleOrgUser, OrgName: "org-1"},
+				{UserGUID: "user-guid", Role: RoleOrgBillingManager, OrgName: "org-1"},
+			}))
+		})
+	})
+
+	Describe("UserPlan.OrgMemberships", func() {
+		It("returns each org of the user and its spaces once", func() {
+			user := UserPlan{
+				Orgs: []OrgPlan{{Name: "org-1"}, {Name: "org-0"}},
+				Spaces: []SpacePlan{
+					{Name: "space-0", OrgName: "org-0"},
+					{Name: "space-1", OrgName: "org-0"},
+					{Name: "space-2", OrgName: "org-2"},
+				},
+			}
+
+			Expect(user.OrgMemberships()).To(Equal([]string{"org-0", "org-2", "org-1"}))
+		})
+	})
+
+	Describe("EnvironmentPlan.Summary", func() {
+		It("counts the org associations saved by associating each user with each org once", func() {
+			summary := NewPlan(config, 42).TestEnvironment.Summary()
+
+			// 8 spaces and 4 orgs, but only 4 memberships
+			Expect(summary.OrgAssociationsSaved).To(Equal(8))
+		})
+	})
+
+	Describe("WriteCSV", func() {
+		It("writes a row per resource and role assignment", func() {
+			buf := bytes.NewBuffer(nil)
+			Expect(NewPlan(config, 42).WriteCSV(buf)).To(Succeed())
+
+			rows, err := csv.NewReader(buf).ReadAll()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(rows[0]).To(Equal([]string{"environment", "kind", "name", "org", "space", "user_guid", "role"}))
+			Expect(rows).To(ContainElement([]string{"test", "space", "perm-test-space-0-in-org-0", "perm-test-org-0", "", "", ""}))
+			Expect(rows).To(ContainElement([]string{"test", "role", "", "perm-test-org-0", "perm-test-space-0-in-org-0", "test-user-guid", "space_developer"}))
+		})
+	})
+})
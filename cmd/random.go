@@ -3,8 +3,9 @@ package cmd
 import (
 	"math"
 	"math/rand"
+	"sort"
 
-	"github.com/cloudfoundry-community/go-cfclient"
+	"github.com/satori/go.uuid"
 )
 
 //go:generate counterfeiter math/rand.Source
@@ -15,18 +16,35 @@ import (
 // It does this by figuring out which "bucket" a randomly sampled user belongs to
 // and returning the number of orgs assigned to the bucket.
 func ChooseNumOrgAssignments(r *rand.Rand, distributions []UserOrgDistribution) uint {
+	return uint(ChooseOrgDistribution(r, distributions).NumOrgs)
+}
+
+// ChooseOrgDistribution returns the "bucket" a randomly sampled user belongs to,
+// or an empty bucket if the percentages do not cover the sample
+func ChooseOrgDistribution(r *rand.Rand, distributions []UserOrgDistribution) UserOrgDistribution {
+	i := ChooseOrgDistributionIndex(r, distributions)
+	if i < 0 {
+		return UserOrgDistribution{}
+	}
+
+	return distributions[i]
+}
+
+// ChooseOrgDistributionIndex returns the index of the "bucket" a randomly sampled user belongs to,
+// or -1 if the percentages do not cover the sample
+func ChooseOrgDistributionIndex(r *rand.Rand, distributions []UserOrgDistribution) int {
 	x := r.Float64()
 
 	var cum float64
-	for _, d := range distributions {
+	for i, d := range distributions {
 		if x > cum && x <= cum+d.PercentUsers {
-			return uint(d.NumOrgs)
+			return i
 		}
 
 		cum += d.PercentUsers
 	}
 
-	return 0
+	return -1
 }
 
 // ChooseNumSpaceAssignments returns a number of space assignments sampled
@@ -35,38 +53,202 @@ func ChooseNumOrgAssignments(r *rand.Rand, distributions []UserOrgDistribution)
 // It does this by figuring out which "bucket" a randomly sampled user belongs to
 // and returning the number of spaces assigned to the bucket.
 func ChooseNumSpaceAssignments(r *rand.Rand, distributions []UserSpaceDistribution) uint {
+	return uint(ChooseSpaceDistribution(r, distributions).NumSpaces)
+}
+
+// ChooseSpaceDistribution returns the "bucket" a randomly sampled user belongs to,
+// or an empty bucket if the percentages do not cover the sample
+func ChooseSpaceDistribution(r *rand.Rand, distributions []UserSpaceDistribution) UserSpaceDistribution {
+	i := ChooseSpaceDistributionIndex(r, distributions)
+	if i < 0 {
+		return UserSpaceDistribution{}
+	}
+
+	return distributions[i]
+}
+
+// ChooseSpaceDistributionIndex returns the index of the "bucket" a randomly sampled user belongs to,
+// or -1 if the percentages do not cover the sample
+func ChooseSpaceDistributionIndex(r *rand.Rand, distributions []UserSpaceDistribution) int {
 	x := r.Float64()
 
 	var cum float64
-	for _, d := range distributions {
+	for i, d := range distributions {
 		if x > cum && x <= cum+d.PercentUsers {
-			return uint(d.NumSpaces)
+			return i
 		}
 
 		cum += d.PercentUsers
 	}
 
-	return 0
+	return -1
 }
 
-// RandomlyChooseOrgs returns a contiguous window of size num of orgs out of the slice
+// ChooseRole returns a role sampled from the mix, or defaultRole if the mix is empty
 //
-// It does this by randomly choosing an index between 0 and (len orgs - window size)
-func RandomlyChooseOrgs(r *rand.Rand, orgs []*cfclient.Org, num uint) []*cfclient.Org {
-	maxIndex := int(math.Min(float64(len(orgs)-int(num)), float64(len(orgs))))
+// An empty mix does not consume from r, so configs without a role mix generate
+// the same plan as before role mixes existed
+func ChooseRole(r *rand.Rand, mix []RoleWeight, defaultRole string) string {
+	if len(mix) == 0 {
+		return defaultRole
+	}
 
-	idx := r.Intn(maxIndex)
+	x := r.Float64()
+
+	var cum float64
+	for _, w := range mix {
+		cum += w.Percent
+		if x < cum {
+			return w.Role
+		}
+	}
+
+	return mix[len(mix)-1].Role
+}
 
-	return orgs[idx:(idx + int(num))]
+// SizeSampler returns a function which samples sizes from the distribution using r,
+// or always returns count if the distribution is not set
+//
+// A sampler for an unset distribution does not consume from r.
+func SizeSampler(r *rand.Rand, d SizeDistribution, count int) func() int {
+	switch {
+	case len(d.Buckets) > 0:
+		return func() int {
+			x := r.Float64()
+
+			var cum float64
+			for _, b := range d.Buckets {
+				cum += b.Percent
+				if x < cum {
+					return b.Count
+				}
+			}
+
+			return d.Buckets[len(d.Buckets)-1].Count
+		}
+	case d.Zipf != nil:
+		z := rand.NewZipf(r, d.Zipf.S, d.Zipf.V, uint64(d.Zipf.Max))
+		return func() int {
+			return int(z.Uint64())
+		}
+	case d.LogNormal != nil:
+		return func() int {
+			size := int(math.Floor(math.Exp(d.LogNormal.Mu+d.LogNormal.Sigma*r.NormFloat64()) + 0.5))
+			if d.LogNormal.Max > 0 && size > d.LogNormal.Max {
+				return d.LogNormal.Max
+			}
+			return size
+		}
+	default:
+		return func() int {
+			return count
+		}
+	}
+}
+
+// ChooseIndices returns num distinct indices out of n, in ascending order, chosen by the strategy
+//
+// SelectionWindow chooses a contiguous window, SelectionUniform samples uniformly without replacement
+// and SelectionZipf samples without replacement with the weight of index i proportional to 1 / (i + 1) ^ zipfExponent,
+// so that the lowest indices are the most popular. If num is at least n every index is returned.
+func ChooseIndices(r *rand.Rand, strategy string, zipfExponent float64, n int, num uint) []int {
+	k := int(math.Min(float64(num), float64(n)))
+
+	var indices []int
+	switch strategy {
+	case SelectionUniform, SelectionLocality:
+		// Floyd's algorithm, which draws k numbers rather than shuffling all n
+		chosen := make(map[int]bool, k)
+		for j := n - k; j < n; j++ {
+			t := r.Intn(j + 1)
+			if chosen[t] {
+				t = j
+			}
+			chosen[t] = true
+			indices = append(indices, t)
+		}
+	case SelectionZipf:
+		// Efraimidis-Spirakis: keep the k indices with the largest log(u) / weight
+		keys := make([]float64, n)
+		indices = make([]int, n)
+		for i := range keys {
+			weight := 1 / math.Pow(float64(i+1), zipfExponent)
+			keys[i] = math.Log(r.Float64()) / weight
+			indices[i] = i
+		}
+		sort.SliceStable(indices, func(a, b int) bool {
+			return keys[indices[a]] > keys[indices[b]]
+		})
+		indices = indices[:k]
+	default:
+		// a window starting at a random index between 0 and (n - window size)
+		idx := r.Intn(n - k + 1)
+		for i := idx; i < idx+k; i++ {
+			indices = append(indices, i)
+		}
+	}
+
+	sort.Ints(indices)
+	return indices
+}
+
+// ChooseOrgs returns num of the orgs, chosen by the strategy as ChooseIndices does
+func ChooseOrgs(r *rand.Rand, strategy string, zipfExponent float64, orgs []OrgPlan, num uint) []OrgPlan {
+	var chosen []OrgPlan
+	for _, i := range ChooseIndices(r, strategy, zipfExponent, len(orgs), num) {
+		chosen = append(chosen, orgs[i])
+	}
+	return chosen
+}
+
+// ChooseSpaces returns num of the spaces, chosen by the strategy as ChooseIndices does
+//
+// SelectionLocality samples uniformly from the spaces of userOrgs first,
+// and only from the rest of the spaces if there are too few of those.
+func ChooseSpaces(r *rand.Rand, strategy string, zipfExponent float64, spaces []SpacePlan, userOrgs []OrgPlan, num uint) []SpacePlan {
+	if strategy != SelectionLocality {
+		var chosen []SpacePlan
+		for _, i := range ChooseIndices(r, strategy, zipfExponent, len(spaces), num) {
+			chosen = append(chosen, spaces[i])
+		}
+		return chosen
+	}
+
+	inUserOrgs := make(map[string]bool)
+	for _, org := range userOrgs {
+		inUserOrgs[org.Name] = true
+	}
+
+	var local, rest []SpacePlan
+	for _, space := range spaces {
+		if inUserOrgs[space.OrgName] {
+			local = append(local, space)
+		} else {
+			rest = append(rest, space)
+		}
+	}
+
+	var chosen []SpacePlan
+	for _, i := range ChooseIndices(r, SelectionUniform, 0, len(local), num) {
+		chosen = append(chosen, local[i])
+	}
+	if len(chosen) < int(num) {
+		for _, i := range ChooseIndices(r, SelectionUniform, 0, len(rest), num-uint(len(chosen))) {
+			chosen = append(chosen, rest[i])
+		}
+	}
+	return chosen
 }
 
-// RandomlyChooseSpaces returns a contiguous window of size num of spaces out of the slice
+// RandomUUID returns a version 4 UUID whose random bits are read from r
 //
-// It does this by randomly choosing an index between 0 and (len spaces - window size)
-func RandomlyChooseSpaces(r *rand.Rand, spaces []*cfclient.Space, num uint) []*cfclient.Space {
-	maxIndex := int(math.Min(float64(len(spaces)-int(num)), float64(len(spaces))))
+// Unlike uuid.NewV4 the result is reproducible given the seed of r
+func RandomUUID(r *rand.Rand) uuid.UUID {
+	var u uuid.UUID
+	r.Read(u[:])
 
-	idx := r.Intn(maxIndex)
+	u.SetVersion(uuid.V4)
+	u.SetVariant(uuid.VariantRFC4122)
 
-	return spaces[idx:(idx + int(num))]
+	return u
 }
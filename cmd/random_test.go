@@ -1,11 +1,13 @@
 package cmd_test
 
 import (
+	"fmt"
 	"math/rand"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/pivotal-cf/perm-test/cmd/cmdfakes"
+	"github.com/satori/go.uuid"
 
 	. "github.com/pivotal-cf/perm-test/cmd"
 )
@@ -61,4 +63,245 @@ var _ = Describe("Random", func() {
 			})
 		})
 	})
+
+	Describe("ChooseRole", func() {
+		var (
+			source *cmdfakes.FakeSource
+			r      *rand.Rand
+
+			mix []RoleWeight
+		)
+
+		BeforeEach(func() {
+			source = new(cmdfakes.FakeSource)
+			r = rand.New(source)
+
+			mix = []RoleWeight{
+				{Role: RoleOrgManager, Percent: 0.1},
+				{Role: RoleOrgAuditor, Percent: 0.9},
+			}
+		})
+
+		It("returns the default role without consuming randomness if the mix is empty", func() {
+			Expect(ChooseRole(r, nil, RoleOrgUser)).To(Equal(RoleOrgUser))
+			Expect(source.Int63CallCount()).To(BeZero())
+		})
+
+		It("returns the role whose share covers the random number", func() {
+			source.Int63Returns(int64(float64(0.05) * float64(1<<63)))
+			Expect(ChooseRole(r, mix, RoleOrgUser)).To(Equal(RoleOrgManager))
+
+			source.Int63Returns(int64(float64(0.5) * float64(1<<63)))
+			Expect(ChooseRole(r, mix, RoleOrgUser)).To(Equal(RoleOrgAuditor))
+		})
+	})
+
+	Describe("SizeSampler", func() {
+		var (
+			source *cmdfakes.FakeSource
+			r      *rand.Rand
+		)
+
+		BeforeEach(func() {
+			source = new(cmdfakes.FakeSource)
+			r = rand.New(source)
+		})
+
+		It("returns the count without consuming randomness if no distribution is set", func() {
+			sample := SizeSampler(r, SizeDistribution{}, 7)
+
+			Expect(sample()).To(Equal(7))
+			Expect(source.Int63CallCount()).To(BeZero())
+		})
+
+		It("returns the count of the bucket whose share covers the random number", func() {
+			sample := SizeSampler(r, SizeDistribution{
+				Buckets: []SizeBucket{
+					{Percent: 0.05, Count: 300},
+					{Percent: 0.95, Count: 2},
+				},
+			}, 7)
+
+			source.Int63Returns(int64(float64(0.01) * float64(1<<63)))
+			Expect(sample()).To(Equal(300))
+
+			source.Int63Returns(int64(float64(0.5) * float64(1<<63)))
+			Expect(sample()).To(Equal(2))
+		})
+
+		It("samples zipf sizes up to the max, mostly small", func() {
+			sample := SizeSampler(rand.New(rand.NewSource(42)), SizeDistribution{
+				Zipf: &ZipfDistribution{S: 1.5, V: 1, Max: 500},
+			}, 0)
+
+			var small, total int
+			for i := 0; i < 1000; i++ {
+				size := sample()
+				Expect(size).To(BeNumerically("<=", 500))
+				if size < 10 {
+					small++
+				}
+				total += size
+			}
+			Expect(small).To(BeNumerically(">", 700))
+			Expect(total).To(BeNumerically(">", 1000))
+		})
+
+		It("samples log-normal sizes capped at the max", func() {
+			sample := SizeSampler(rand.New(rand.NewSource(42)), SizeDistribution{
+				LogNormal: &LogNormalDistribution{Mu: 2, Sigma: 1.5, Max: 100},
+			}, 0)
+
+			var capped int
+			for i := 0; i < 1000; i++ {
+				size := sample()
+				Expect(size).To(BeNumerically(">=", 0))
+				Expect(size).To(BeNumerically("<=", 100))
+				if size == 100 {
+					capped++
+				}
+			}
+			Expect(capped).To(BeNumerically(">", 0))
+		})
+	})
+
+	Describe("selection", func() {
+		var (
+			source *cmdfakes.FakeSource
+			r      *rand.Rand
+
+			orgs   []OrgPlan
+			spaces []SpacePlan
+		)
+
+		BeforeEach(func() {
+			source = new(cmdfakes.FakeSource)
+			r = rand.New(source)
+
+			orgs = make([]OrgPlan, 5)
+			for i := range orgs {
+				orgs[i] = OrgPlan{Name: fmt.Sprintf("org-%d", i)}
+				for j := 0; j < 2; j++ {
+					spaces = append(spaces, SpacePlan{Name: fmt.Sprintf("space-%d-in-org-%d", j, i), OrgName: orgs[i].Name})
+				}
+			}
+		})
+
+		AfterEach(func() {
+			spaces = nil
+		})
+
+		Describe("ChooseIndices", func() {
+			It("samples uniformly without replacement", func() {
+				// every draw is 0, so Floyd's algorithm falls back to the top of each range
+				source.Int63Returns(0)
+
+				Expect(ChooseIndices(r, SelectionUniform, 0, 10, 4)).To(Equal([]int{0, 7, 8, 9}))
+			})
+
+			It("samples distinct indices from a real source", func() {
+				indices := ChooseIndices(rand.New(rand.NewSource(42)), SelectionUniform, 0, 100, 50)
+
+				Expect(indices).To(HaveLen(50))
+				seen := make(map[int]bool)
+				for _, i := range indices {
+					Expect(seen[i]).To(BeFalse())
+					seen[i] = true
+				}
+			})
+
+			It("favours the most popular indices with zipf sampling", func() {
+				source.Int63Returns(int64(float64(0.5) * float64(1<<63)))
+
+				Expect(ChooseIndices(r, SelectionZipf, 1, 10, 3)).To(Equal([]int{0, 1, 2}))
+			})
+
+			It("chooses popular indices more often with a real source", func() {
+				r = rand.New(rand.NewSource(42))
+
+				counts := make([]int, 100)
+				for i := 0; i < 200; i++ {
+					for _, j := range ChooseIndices(r, SelectionZipf, 1.5, 100, 5) {
+						counts[j]++
+					}
+				}
+				Expect(counts[0]).To(BeNumerically(">", 5*counts[50]+50))
+			})
+
+			It("returns every index when asked for more than there are", func() {
+				Expect(ChooseIndices(r, SelectionZipf, 1, 3, 5)).To(Equal([]int{0, 1, 2}))
+				Expect(ChooseIndices(r, SelectionUniform, 0, 3, 5)).To(Equal([]int{0, 1, 2}))
+				Expect(ChooseIndices(r, SelectionWindow, 0, 3, 5)).To(Equal([]int{0, 1, 2}))
+			})
+
+			It("can choose the first and the last window", func() {
+				source.Int63Returns(0)
+				Expect(ChooseIndices(r, SelectionWindow, 0, 5, 2)).To(Equal([]int{0, 1}))
+
+				source.Int63Returns(1<<63 - 1)
+				Expect(ChooseIndices(r, SelectionWindow, 0, 5, 2)).To(Equal([]int{3, 4}))
+			})
+		})
+
+		Describe("ChooseOrgs", func() {
+			It("returns every org rather than panicking when asked for all of them", func() {
+				Expect(ChooseOrgs(r, SelectionWindow, 0, orgs, 5)).To(Equal(orgs))
+				Expect(ChooseOrgs(r, SelectionWindow, 0, orgs, 6)).To(Equal(orgs))
+			})
+
+			It("can choose the last window", func() {
+				source.Int63Returns(1<<63 - 1)
+
+				Expect(ChooseOrgs(r, SelectionWindow, 0, orgs, 2)).To(Equal(orgs[3:]))
+			})
+		})
+
+		Describe("ChooseSpaces", func() {
+			It("returns every space rather than panicking when asked for all of them", func() {
+				Expect(ChooseSpaces(r, SelectionWindow, 0, spaces, nil, 10)).To(Equal(spaces))
+			})
+
+			It("draws spaces from the user's orgs with locality", func() {
+				source.Int63Returns(int64(float64(0.5) * float64(1<<63)))
+
+				chosen := ChooseSpaces(r, SelectionLocality, 0, spaces, []OrgPlan{orgs[1], orgs[3]}, 3)
+
+				Expect(chosen).To(HaveLen(3))
+				for _, space := range chosen {
+					Expect([]string{"org-1", "org-3"}).To(ContainElement(space.OrgName))
+				}
+			})
+
+			It("tops up from the other orgs when the user's orgs have too few spaces", func() {
+				source.Int63Returns(0)
+
+				chosen := ChooseSpaces(r, SelectionLocality, 0, spaces, []OrgPlan{orgs[2]}, 4)
+
+				Expect(chosen).To(HaveLen(4))
+				Expect(chosen[:2]).To(Equal(spaces[4:6]))
+				for _, space := range chosen[2:] {
+					Expect(space.OrgName).NotTo(Equal("org-2"))
+				}
+			})
+		})
+	})
+
+	Describe("RandomUUID", func() {
+		It("generates the same version 4 UUIDs from the same seed", func() {
+			r1 := rand.New(rand.NewSource(42))
+			r2 := rand.New(rand.NewSource(42))
+
+			for i := 0; i < 10; i++ {
+				u := RandomUUID(r1)
+				Expect(u.Version()).To(Equal(uuid.V4))
+				Expect(u.Variant()).To(Equal(uuid.VariantRFC4122))
+
+				Expect(u).To(Equal(RandomUUID(r2)))
+			}
+		})
+
+		It("generates different UUIDs from different seeds", func() {
+			Expect(RandomUUID(rand.New(rand.NewSource(1)))).NotTo(Equal(RandomUUID(rand.New(rand.NewSource(2)))))
+		})
+	})
 })
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/cloudfoundry-community/go-cfclient"
+	"github.com/pivotal-cf/perm-test/cmd"
+	"github.com/pivotal-cf/perm-test/experiment"
+	"github.com/pivotal-cf/perm-test/metrics"
+	"github.com/pivotal-cf/perm-test/report"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	RequestTimeout = 10 * time.Minute
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Println("Usage: runexperiment <path/to/config.yml>")
+		os.Exit(2)
+	}
+
+	configPath := os.Args[1]
+	contents, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		fmt.Printf("Error reading config file: %s\n", err.Error())
+		panic(err)
+	}
+
+	var config cmd.RunExperimentConfig
+	err = yaml.Unmarshal(contents, &config)
+	if err != nil {
+		fmt.Printf("Failed to parse config file data: %s\n", err.Error())
+		panic(err)
+	}
+
+	logger := config.NewLogger("perm-runexperiment")
+	err = config.Validate()
+	if err != nil {
+		logger.Error("failed-to-validate-config", err)
+		panic(err)
+	}
+
+	logger.Info("starting")
+	defer logger.Info("finished")
+
+	startedAt := time.Now()
+	recorder := report.NewRecorder()
+	var results []*experiment.Result
+
+	tlsConfig, err := config.CloudControllerConfig.NewTLSConfig()
+	if err != nil {
+		logger.Error("failed-to-load-cloud-controller-ca-cert", err)
+		panic(err)
+	}
+
+	tokenSource := newTokenSource(logger, config.CloudControllerConfig, tlsConfig, config.UserConfig.Username, config.UserConfig.Password)
+
+	var personas []persona
+	if config.PersonaConfig.Enabled() {
+		personas = loadPersonas(logger, config.CloudControllerConfig, tlsConfig, config.PersonaConfig)
+	}
+
+	maxConcurrency := 1
+	for _, e := range config.ExperimentConfig.Endpoints {
+		for _, r := range e.Runs {
+			if r.Concurrency > maxConcurrency {
+				maxConcurrency = r.Concurrency
+			}
+		}
+	}
+
+	registry := metrics.NewRegistry()
+	if config.MetricsAddress != "" {
+		go func() {
+			logger.Info("serving-metrics", lager.Data{
+				"address": config.MetricsAddress,
+			})
+
+			err := metrics.Serve(config.MetricsAddress, registry)
+			if err != nil {
+				logger.Error("failed-to-serve-metrics", err)
+			}
+		}()
+	}
+
+	timeout := config.CloudControllerConfig.Timeout
+	if timeout == 0 {
+		timeout = RequestTimeout
+	}
+
+	runner := &experiment.Runner{
+		APIAddress:  config.CloudControllerConfig.URL,
+		TokenSource: tokenSource,
+		HTTPClient: &http.Client{
+			Timeout: timeout,
+			Transport: metrics.NewTransport(registry, "perm_test_experiment_request_duration_seconds", &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				MaxIdleConnsPerHost: maxConcurrency,
+				TLSClientConfig:     tlsConfig,
+			}),
+		},
+	}
+
+	ctx, stop := cmd.WithInterrupt(context.Background(), logger)
+	defer stop()
+
+endpoints:
+	for _, e := range config.ExperimentConfig.Endpoints {
+		endPhase := recorder.StartPhase("warmup " + e.Path)
+		err = runner.Warmup(ctx, logger, e.Path, config.ExperimentConfig.WarmupRequests)
+		endPhase()
+		if ctx.Err() != nil {
+			break
+		}
+		if err != nil {
+			logger.Error("failed-to-warm-up", err)
+			panic(err)
+		}
+
+		for _, r := range e.Runs {
+			runs := []experiment.Run{{
+				Path:        e.Path,
+				Requests:    r.Requests,
+				Concurrency: r.Concurrency,
+			}}
+			for _, p := range personas {
+				runs = append(runs, experiment.Run{
+					Path:         e.Path,
+					Requests:     r.Requests,
+					Concurrency:  r.Concurrency,
+					Bucket:       p.bucket.String(),
+					TokenSources: p.tokenSources,
+				})
+			}
+
+			for _, run := range runs {
+				phase := fmt.Sprintf("run %s concurrency=%d", run.Path, run.Concurrency)
+				if run.Bucket != "" {
+					phase += " bucket=" + run.Bucket
+				}
+
+				endPhase := recorder.StartPhase(phase)
+				result, err := runner.Run(ctx, logger, run)
+				endPhase()
+				if result != nil {
+					results = append(results, result)
+					logResult(logger, result)
+				}
+				if ctx.Err() != nil {
+					break endpoints
+				}
+				if err != nil {
+					logger.Error("failed-to-run-experiment", err)
+					panic(err)
+				}
+			}
+		}
+	}
+
+	if config.ReportPath != "" {
+		doc := report.NewDocument("runexperiment", config.Redacted(), startedAt, recorder)
+		doc.Experiments = results
+
+		err = doc.WriteFile(config.ReportPath)
+		if err != nil {
+			logger.Error("failed-to-write-report", err)
+			panic(err)
+		}
+	}
+
+	if ctx.Err() != nil {
+		logger.Info("stopped-before-finishing", lager.Data{
+			"completed-runs": len(results),
+		})
+		os.Exit(cmd.InterruptExitCode)
+	}
+}
+
+// persona is a bucket of the external environment and the users sampled from it
+type persona struct {
+	bucket       cmd.Bucket
+	tokenSources []experiment.TokenSource
+}
+
+// loadPersonas logs in as the users sampled from each bucket of the external
+// environment described by the loaddata config
+func loadPersonas(logger lager.Logger, ccConfig cmd.CloudControllerConfig, tlsConfig *tls.Config, config cmd.PersonaConfig) []persona {
+	contents, err := ioutil.ReadFile(config.LoadDataConfigPath)
+	if err != nil {
+		logger.Error("failed-to-read-loaddata-config", err)
+		panic(err)
+	}
+
+	var loadDataConfig cmd.LoadDataConfig
+	err = yaml.Unmarshal(contents, &loadDataConfig)
+	if err != nil {
+		logger.Error("failed-to-parse-loaddata-config", err)
+		panic(err)
+	}
+
+	buckets, password, err := loadDataConfig.Personas(config.UsersPerBucket)
+	if err != nil {
+		logger.Error("failed-to-choose-personas", err)
+		panic(err)
+	}
+
+	var personas []persona
+	for _, b := range buckets {
+		p := persona{bucket: b.Bucket}
+		for _, u := range b.Users {
+			p.tokenSources = append(p.tokenSources, newTokenSource(logger, ccConfig, tlsConfig, u.Name, password))
+		}
+
+		logger.Info("logged-in-as-personas", lager.Data{
+			"bucket": b.Bucket.String(),
+			"users":  len(b.Users),
+		})
+		personas = append(personas, p)
+	}
+
+	return personas
+}
+
+// newTokenSource logs in to Cloud Controller as the user
+func newTokenSource(logger lager.Logger, config cmd.CloudControllerConfig, tlsConfig *tls.Config, username string, password string) *userTokenSource {
+	ts := &userTokenSource{
+		logger: logger.Session("token-source", lager.Data{
+			"username": username,
+		}),
+		login: func() (*cfclient.Client, error) {
+			return cfclient.NewClient(&cfclient.Config{
+				ApiAddress:        config.URL,
+				Username:          username,
+				Password:          password,
+				SkipSslValidation: config.SkipSSLValidation(),
+				HttpClient: &http.Client{
+					Transport: &http.Transport{
+						Proxy:           http.ProxyFromEnvironment,
+						TLSClientConfig: tlsConfig.Clone(),
+					},
+				},
+			})
+		},
+	}
+
+	var err error
+	ts.client, err = ts.login()
+	if err != nil {
+		logger.Error("failed-to-make-cf-client", err, lager.Data{
+			"username": username,
+		})
+		panic(err)
+	}
+
+	return ts
+}
+
+func logResult(logger lager.Logger, result *experiment.Result) {
+	logger.Info("result", lager.Data{
+		"path":                result.Path,
+		"bucket":              result.Bucket,
+		"users":               result.Users,
+		"requests":            result.Requests,
+		"concurrency":         result.Concurrency,
+		"failures":            result.Failures,
+		"duration":            result.Duration.String(),
+		"requests-per-second": result.RequestsPerSecond(),
+		"latency.min":         result.Latencies.Min.String(),
+		"latency.mean":        result.Latencies.Mean.String(),
+		"latency.p50":         result.Latencies.P50.String(),
+		"latency.p90":         result.Latencies.P90.String(),
+		"latency.p99":         result.Latencies.P99.String(),
+		"latency.max":         result.Latencies.Max.String(),
+	})
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/cloudfoundry-community/go-cfclient"
+)
+
+// userTokenSource is a user's Cloud Controller token
+//
+// cfclient refreshes the token as it expires. If Cloud Controller rejects it anyway,
+// for example because it was revoked, the user logs in again.
+type userTokenSource struct {
+	logger lager.Logger
+	login  func() (*cfclient.Client, error)
+
+	mu     sync.Mutex
+	client *cfclient.Client
+}
+
+func (s *userTokenSource) GetToken() (string, error) {
+	s.mu.Lock()
+	client := s.client
+	s.mu.Unlock()
+
+	return client.GetToken()
+}
+
+// RefreshToken logs in again, unless another request has already replaced the rejected token
+func (s *userTokenSource) RefreshToken(rejected string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, err := s.client.GetToken()
+	if err == nil && token != rejected {
+		return token, nil
+	}
+
+	s.logger.Info("logging-in-again")
+	client, err := s.login()
+	if err != nil {
+		s.logger.Error("failed-to-log-in-again", err)
+		return "", err
+	}
+	s.client = client
+
+	return client.GetToken()
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// InterruptExitCode is the status a command exits with after being interrupted,
+// following the shell convention of 128 plus the number of SIGINT
+const InterruptExitCode = 130
+
+// WithInterrupt returns a context which is cancelled when the process receives SIGINT or SIGTERM,
+// so that work in flight can finish and the results so far be saved before exiting
+//
+// A second signal exits immediately. The returned function stops listening for signals.
+func WithInterrupt(parent context.Context, logger lager.Logger) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	stopped := make(chan struct{})
+	go func() {
+		select {
+		case sig := <-signals:
+			logger.Info("interrupted", lager.Data{
+				"signal": sig.String(),
+				"hint":   "waiting for operations in flight to finish, signal again to exit immediately",
+			})
+			cancel()
+		case <-stopped:
+			return
+		}
+
+		select {
+		case sig := <-signals:
+			logger.Info("exiting-immediately", lager.Data{
+				"signal": sig.String(),
+			})
+			os.Exit(InterruptExitCode)
+		case <-stopped:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(stopped)
+		cancel()
+	}
+}
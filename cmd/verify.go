@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+const (
+	DriftMissingOrg    = "missing_org"
+	DriftUnexpectedOrg = "unexpected_org"
+	DriftSpaceCount    = "space_count"
+	DriftMissingSpace  = "missing_space"
+	DriftAppCount      = "app_count"
+	DriftMissingRole   = "missing_role"
+	DriftMissingUser   = "missing_user"
+)
+
+// Inventory is the part of the dataset observed in Cloud Controller
+type Inventory struct {
+	// Orgs maps the name of every seeded org to the app count of each of its spaces, by space name
+	Orgs map[string]map[string]int
+
+	// Roles are the role assignments of the users whose roles were looked up
+	Roles map[RoleAssignment]bool
+
+	// CheckedUsers are the GUIDs of the users whose roles were looked up
+	CheckedUsers map[string]bool
+
+	// MissingUsers are the GUIDs of the checked users which do not exist in Cloud Controller
+	MissingUsers map[string]bool
+}
+
+func NewInventory() *Inventory {
+	return &Inventory{
+		Orgs:         make(map[string]map[string]int),
+		Roles:        make(map[RoleAssignment]bool),
+		CheckedUsers: make(map[string]bool),
+		MissingUsers: make(map[string]bool),
+	}
+}
+
+// Drift is a single difference between the config and the observed environment
+type Drift struct {
+	Kind     string `json:"kind"`
+	Org      string `json:"org,omitempty"`
+	Space    string `json:"space,omitempty"`
+	UserGUID string `json:"user_guid,omitempty"`
+	Role     string `json:"role,omitempty"`
+	Expected int    `json:"expected,omitempty"`
+	Actual   int    `json:"actual,omitempty"`
+}
+
+type VerifyReport struct {
+	OK            bool    `json:"ok"`
+	OrgsChecked   int     `json:"orgs_checked"`
+	SpacesChecked int     `json:"spaces_checked"`
+	UsersChecked  int     `json:"users_checked"`
+	Drift         []Drift `json:"drift"`
+}
+
+// Verify compares the observed inventory against the plan
+//
+// Every planned org must exist with its planned number of spaces, each with its planned number of apps,
+// and every user whose roles were looked up must exist and hold all of their planned roles.
+// Seeded orgs which are not in the plan are reported as unexpected.
+func Verify(plan *Plan, inv *Inventory) VerifyReport {
+	report := VerifyReport{
+		Drift: []Drift{},
+	}
+
+	planned := make(map[string]bool)
+	for _, env := range []EnvironmentPlan{plan.TestEnvironment, plan.ExternalEnvironment} {
+		for _, org := range env.Orgs {
+			planned[org.Name] = true
+			report.OrgsChecked++
+
+			spaces, ok := inv.Orgs[org.Name]
+			if !ok {
+				report.Drift = append(report.Drift, Drift{Kind: DriftMissingOrg, Org: org.Name})
+				continue
+			}
+
+			if len(spaces) != len(org.Spaces) {
+				report.Drift = append(report.Drift, Drift{
+					Kind:     DriftSpaceCount,
+					Org:      org.Name,
+					Expected: len(org.Spaces),
+					Actual:   len(spaces),
+				})
+			}
+
+			for _, space := range org.Spaces {
+				report.SpacesChecked++
+
+				apps, ok := spaces[space.Name]
+				if !ok {
+					report.Drift = append(report.Drift, Drift{Kind: DriftMissingSpace, Org: org.Name, Space: space.Name})
+					continue
+				}
+
+				if apps != len(space.Apps) {
+					report.Drift = append(report.Drift, Drift{
+						Kind:     DriftAppCount,
+						Org:      org.Name,
+						Space:    space.Name,
+						Expected: len(space.Apps),
+						Actual:   apps,
+					})
+				}
+			}
+		}
+
+		for _, u := range env.Users {
+			if !inv.CheckedUsers[u.GUID] {
+				continue
+			}
+			report.UsersChecked++
+
+			if inv.MissingUsers[u.GUID] {
+				report.Drift = append(report.Drift, Drift{Kind: DriftMissingUser, UserGUID: u.GUID})
+			}
+
+			for _, a := range u.RoleAssignments() {
+				if !inv.Roles[a] {
+					report.Drift = append(report.Drift, Drift{
+						Kind:     DriftMissingRole,
+						Org:      a.OrgName,
+						Space:    a.SpaceName,
+						UserGUID: a.UserGUID,
+						Role:     a.Role,
+					})
+				}
+			}
+		}
+	}
+
+	var unexpected []string
+	for name := range inv.Orgs {
+		if !planned[name] {
+			unexpected = append(unexpected, name)
+		}
+	}
+	sort.Strings(unexpected)
+	for _, name := range unexpected {
+		report.Drift = append(report.Drift, Drift{Kind: DriftUnexpectedOrg, Org: name})
+	}
+
+	report.OK = len(report.Drift) == 0
+	return report
+}
+
+func (r VerifyReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(r)
+}
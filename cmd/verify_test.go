@@ -0,0 +1,116 @@
+package cmd_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/pivotal-cf/perm-test/cmd"
+)
+
+var _ = Describe("Verify", func() {
+	var (
+		config TestDataConfig
+		plan   *Plan
+		inv    *Inventory
+	)
+
+	BeforeEach(func() {
+		config = TestDataConfig{
+			SpacesPerOrgCount: 2,
+			AppsPerSpaceCount: 1,
+			TestEnvironmentConfig: TestEnvironmentConfig{
+				UserGUID: "test-user-guid",
+				OrgCount: 2,
+			},
+		}
+		plan = NewPlan(config, 42)
+
+		// an inventory which exactly matches the plan
+		inv = NewInventory()
+		for _, org := range plan.TestEnvironment.Orgs {
+			inv.Orgs[org.Name] = make(map[string]int)
+			for _, space := range org.Spaces {
+				inv.Orgs[org.Name][space.Name] = 1
+			}
+		}
+		for _, a := range plan.TestEnvironment.Users[0].RoleAssignments() {
+			inv.Roles[a] = true
+		}
+		inv.CheckedUsers["test-user-guid"] = true
+	})
+
+	It("reports no drift when the environment matches the plan", func() {
+		report := Verify(plan, inv)
+
+		Expect(report.OK).To(BeTrue())
+		Expect(report.Drift).To(BeEmpty())
+		Expect(report.OrgsChecked).To(Equal(2))
+		Expect(report.SpacesChecked).To(Equal(4))
+		Expect(report.UsersChecked).To(Equal(1))
+	})
+
+	It("reports missing orgs and spaces and wrong counts", func() {
+		delete(inv.Orgs, "perm-test-org-0")
+		delete(inv.Orgs["perm-test-org-1"], "perm-test-space-0-in-org-1")
+		inv.Orgs["perm-test-org-1"]["perm-test-space-1-in-org-1"] = 3
+
+		report := Verify(plan, inv)
+
+		Expect(report.OK).To(BeFalse())
+		Expect(report.Drift).To(ConsistOf(
+			Drift{Kind: DriftMissingOrg, Org: "perm-test-org-0"},
+			Drift{Kind: DriftSpaceCount, Org: "perm-test-org-1", Expected: 2, Actual: 1},
+			Drift{Kind: DriftMissingSpace, Org: "perm-test-org-1", Space: "perm-test-space-0-in-org-1"},
+			Drift{Kind: DriftAppCount, Org: "perm-test-org-1", Space: "perm-test-space-1-in-org-1", Expected: 1, Actual: 3},
+		))
+	})
+
+	It("reports seeded orgs which are not in the plan", func() {
+		inv.Orgs["perm-external-org-7"] = map[string]int{}
+
+		report := Verify(plan, inv)
+
+		Expect(report.Drift).To(ConsistOf(Drift{Kind: DriftUnexpectedOrg, Org: "perm-external-org-7"}))
+	})
+
+	It("reports the roles the test user is missing", func() {
+		delete(inv.Roles, RoleAssignment{
+			UserGUID:  "test-user-guid",
+			Role:      RoleSpaceDeveloper,
+			OrgName:   "perm-test-org-1",
+			SpaceName: "perm-test-space-1-in-org-1",
+		})
+
+		report := Verify(plan, inv)
+
+		Expect(report.Drift).To(ConsistOf(Drift{
+			Kind:     DriftMissingRole,
+			Org:      "perm-test-org-1",
+			Space:    "perm-test-space-1-in-org-1",
+			UserGUID: "test-user-guid",
+			Role:     RoleSpaceDeveloper,
+		}))
+	})
+
+	It("reports users missing from Cloud Controller along with all of their roles", func() {
+		inv.Roles = map[RoleAssignment]bool{}
+		inv.MissingUsers["test-user-guid"] = true
+
+		report := Verify(plan, inv)
+
+		Expect(report.OK).To(BeFalse())
+		Expect(report.UsersChecked).To(Equal(1))
+		Expect(report.Drift).To(ContainElement(Drift{Kind: DriftMissingUser, UserGUID: "test-user-guid"}))
+		Expect(report.Drift).To(HaveLen(1 + len(plan.TestEnvironment.Users[0].RoleAssignments())))
+	})
+
+	It("does not check the roles of users who were not looked up", func() {
+		inv.CheckedUsers = map[string]bool{}
+		inv.Roles = map[RoleAssignment]bool{}
+
+		report := Verify(plan, inv)
+
+		Expect(report.OK).To(BeTrue())
+		Expect(report.UsersChecked).To(BeZero())
+	})
+})
@@ -0,0 +1,13 @@
+package experiment_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestExperiment(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Experiment Suite")
+}
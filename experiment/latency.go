@@ -0,0 +1,106 @@
+package experiment
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+type LatencySummary struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+// SummarizeLatencies returns the min, mean, max and percentiles of the latencies
+//
+// Percentiles are calculated using the nearest-rank method
+func SummarizeLatencies(latencies []time.Duration) LatencySummary {
+	if len(latencies) == 0 {
+		return LatencySummary{}
+	}
+
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+
+	return LatencySummary{
+		Min:  sorted[0],
+		Mean: total / time.Duration(len(sorted)),
+		P50:  Percentile(sorted, 50),
+		P90:  Percentile(sorted, 90),
+		P99:  Percentile(sorted, 99),
+		Max:  sorted[len(sorted)-1],
+	}
+}
+
+// Percentile returns the pth percentile of an ascending slice of latencies
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+
+	return sorted[rank]
+}
+
+type HistogramBucket struct {
+	// UpperBound is the exclusive upper bound of the bucket, or 0 for the final, unbounded bucket
+	UpperBound time.Duration `json:"upper_bound"`
+	Count      int           `json:"count"`
+}
+
+// HistogramBounds are the upper bounds of the latency histogram buckets,
+// doubling from 1ms to ~65s
+var HistogramBounds = func() []time.Duration {
+	var bounds []time.Duration
+	for b := time.Millisecond; b <= 65536*time.Millisecond; b *= 2 {
+		bounds = append(bounds, b)
+	}
+	return bounds
+}()
+
+// Histogram counts the latencies falling into each of the HistogramBounds buckets
+//
+// Empty buckets are omitted
+func Histogram(latencies []time.Duration) []HistogramBucket {
+	counts := make([]int, len(HistogramBounds)+1)
+	for _, l := range latencies {
+		i := sort.Search(len(HistogramBounds), func(i int) bool {
+			return l < HistogramBounds[i]
+		})
+		counts[i]++
+	}
+
+	buckets := []HistogramBucket{}
+	for i, c := range counts {
+		if c == 0 {
+			continue
+		}
+
+		var bound time.Duration
+		if i < len(HistogramBounds) {
+			bound = HistogramBounds[i]
+		}
+		buckets = append(buckets, HistogramBucket{UpperBound: bound, Count: c})
+	}
+
+	return buckets
+}
@@ -0,0 +1,33 @@
+package experiment_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/pivotal-cf/perm-test/experiment"
+)
+
+var _ = Describe("Histogram", func() {
+	It("counts latencies into doubling buckets, omitting empty ones", func() {
+		buckets := Histogram([]time.Duration{
+			500 * time.Microsecond,
+			time.Millisecond,
+			3 * time.Millisecond,
+			3 * time.Millisecond,
+			10 * time.Minute,
+		})
+
+		Expect(buckets).To(Equal([]HistogramBucket{
+			{UpperBound: time.Millisecond, Count: 1},
+			{UpperBound: 2 * time.Millisecond, Count: 1},
+			{UpperBound: 4 * time.Millisecond, Count: 2},
+			{UpperBound: 0, Count: 1},
+		}))
+	})
+
+	It("returns no buckets for no latencies", func() {
+		Expect(Histogram(nil)).To(BeEmpty())
+	})
+})
@@ -0,0 +1,242 @@
+package experiment
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+)
+
+// TokenSource provides the value of the Authorization header sent with every request
+//
+// *cfclient.Client satisfies this interface, fetching and refreshing the token from UAA
+type TokenSource interface {
+	GetToken() (string, error)
+}
+
+// TokenRefresher is a TokenSource which can replace a token the API has rejected
+type TokenRefresher interface {
+	TokenSource
+
+	// RefreshToken returns a new token if rejected is still the current one,
+	// or the current one if another request has already replaced it
+	RefreshToken(rejected string) (string, error)
+}
+
+type Run struct {
+	Path        string
+	Requests    int
+	Concurrency int
+
+	// Bucket and TokenSources, if set, are a group of users the requests are
+	// made as in turn, instead of the runner's TokenSource
+	Bucket       string
+	TokenSources []TokenSource
+}
+
+type Result struct {
+	Path        string            `json:"path"`
+	Bucket      string            `json:"bucket,omitempty"`
+	Users       int               `json:"users,omitempty"`
+	Requests    int               `json:"requests"`
+	Concurrency int               `json:"concurrency"`
+	Failures    int               `json:"failures"`
+	Duration    time.Duration     `json:"duration"`
+	Latencies   LatencySummary    `json:"latencies"`
+	Histogram   []HistogramBucket `json:"histogram"`
+}
+
+// RequestsPerSecond returns the throughput of successful and failed requests over the run
+func (r *Result) RequestsPerSecond() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+
+	return float64(r.Requests) / r.Duration.Seconds()
+}
+
+type Runner struct {
+	APIAddress  string
+	HTTPClient  *http.Client
+	TokenSource TokenSource
+}
+
+// Warmup sends num sequential requests to the path, discarding the results
+func (r *Runner) Warmup(ctx context.Context, logger lager.Logger, path string, num int) error {
+	logger = logger.Session("warmup", lager.Data{
+		"path":     path,
+		"requests": num,
+	})
+
+	if num <= 0 {
+		return nil
+	}
+
+	_, err := r.Run(ctx, logger, Run{
+		Path:        path,
+		Requests:    num,
+		Concurrency: 1,
+	})
+	return err
+}
+
+// Run sends run.Requests GET requests to run.Path split across run.Concurrency workers
+//
+// Requests are made as each of run.TokenSources in turn, or the runner's TokenSource if there are none.
+// Each token source is asked for a token up front, so that the run fails fast if a user cannot log in,
+// and then before every request, so that tokens are refreshed as they expire during long runs.
+// Every request is timed from being sent until the response body has been read.
+// Requests which error or return a non-2xx status code are counted as failures
+// but their latencies are still recorded. A failure to get a token is counted as a failure
+// without a latency.
+func (r *Runner) Run(ctx context.Context, logger lager.Logger, run Run) (*Result, error) {
+	logger = logger.Session("run", lager.Data{
+		"path":        run.Path,
+		"bucket":      run.Bucket,
+		"requests":    run.Requests,
+		"concurrency": run.Concurrency,
+	})
+
+	tokenSources := run.TokenSources
+	if len(tokenSources) == 0 {
+		tokenSources = []TokenSource{r.TokenSource}
+	}
+
+	for _, ts := range tokenSources {
+		_, err := ts.GetToken()
+		if err != nil {
+			logger.Error("failed-to-get-token", err)
+			return nil, err
+		}
+	}
+
+	url := r.APIAddress + run.Path
+
+	var (
+		mu        sync.Mutex
+		latencies = make([]time.Duration, 0, run.Requests)
+		requested int
+		failures  int
+	)
+
+	requests := make(chan int, run.Requests)
+	for i := 0; i < run.Requests; i++ {
+		requests <- i
+	}
+	close(requests)
+
+	logger.Debug("starting")
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := 0; i < run.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range requests {
+				if ctx.Err() != nil {
+					return
+				}
+
+				latency, sent, err := r.request(ctx, url, tokenSources[i%len(tokenSources)])
+				if err != nil {
+					logger.Debug("request-failed", lager.Data{
+						"error": err.Error(),
+					})
+				}
+
+				mu.Lock()
+				requested++
+				if sent {
+					latencies = append(latencies, latency)
+				}
+				if err != nil {
+					failures++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	result := &Result{
+		Path:        run.Path,
+		Bucket:      run.Bucket,
+		Users:       len(run.TokenSources),
+		Requests:    requested,
+		Concurrency: run.Concurrency,
+		Failures:    failures,
+		Duration:    time.Since(start),
+		Latencies:   SummarizeLatencies(latencies),
+		Histogram:   Histogram(latencies),
+	}
+
+	logger.Debug("finished", lager.Data{
+		"failures": failures,
+	})
+
+	return result, ctx.Err()
+}
+
+// request makes a request to url with a token from ts, returning its latency and whether it was sent
+//
+// If the token is rejected and ts is a TokenRefresher, the request is repeated once with a new token,
+// and the latency of the repeat is returned.
+func (r *Runner) request(ctx context.Context, url string, ts TokenSource) (time.Duration, bool, error) {
+	token, err := ts.GetToken()
+	if err != nil {
+		return 0, false, err
+	}
+
+	latency, status, err := r.do(ctx, url, token)
+	if status != http.StatusUnauthorized {
+		return latency, true, err
+	}
+
+	refresher, ok := ts.(TokenRefresher)
+	if !ok {
+		return latency, true, err
+	}
+
+	token, err = refresher.RefreshToken(token)
+	if err != nil {
+		return latency, true, err
+	}
+
+	latency, _, err = r.do(ctx, url, token)
+	return latency, true, err
+}
+
+func (r *Runner) do(ctx context.Context, url string, token string) (time.Duration, int, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Authorization", token)
+
+	start := time.Now()
+	resp, err := r.HTTPClient.Do(req)
+	if err != nil {
+		return time.Since(start), 0, err
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(ioutil.Discard, resp.Body)
+	latency := time.Since(start)
+	if err != nil {
+		return latency, resp.StatusCode, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return latency, resp.StatusCode, fmt.Errorf("Incorrect status code (%d)", resp.StatusCode)
+	}
+
+	return latency, resp.StatusCode, nil
+}
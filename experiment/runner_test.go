@@ -0,0 +1,206 @@
+package experiment_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/cloudfoundry-community/go-cfclient"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/pivotal-cf/perm-test/experiment"
+)
+
+var _ = Describe("Runner", func() {
+	var (
+		server *ghttp.Server
+
+		runner *Runner
+		logger *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("GET", "/v2/info"),
+			ghttp.RespondWith(200, "{}", nil),
+		))
+
+		cfClient, err := cfclient.NewClient(&cfclient.Config{
+			ApiAddress: "http://" + server.Addr(),
+			Token:      "foobar",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		runner = &Runner{
+			APIAddress:  "http://" + server.Addr(),
+			HTTPClient:  &http.Client{},
+			TokenSource: cfClient,
+		}
+
+		logger = lagertest.NewTestLogger("runner")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Run", func() {
+		It("sends the requested number of authorized requests to the path", func() {
+			server.RouteToHandler("GET", "/v3/apps", ghttp.CombineHandlers(
+				ghttp.VerifyHeaderKV("Authorization", "bearer foobar"),
+				ghttp.RespondWith(200, `{"resources": []}`, nil),
+			))
+
+			result, err := runner.Run(context.Background(), logger, Run{
+				Path:        "/v3/apps",
+				Requests:    20,
+				Concurrency: 4,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(server.ReceivedRequests()).To(HaveLen(21))
+			Expect(result.Path).To(Equal("/v3/apps"))
+			Expect(result.Requests).To(Equal(20))
+			Expect(result.Concurrency).To(Equal(4))
+			Expect(result.Failures).To(Equal(0))
+			Expect(result.Latencies.Max).To(BeNumerically(">=", result.Latencies.P50))
+		})
+
+		It("counts non-2xx responses as failures", func() {
+			server.RouteToHandler("GET", "/v2/apps", ghttp.RespondWith(503, "", nil))
+
+			result, err := runner.Run(context.Background(), logger, Run{
+				Path:        "/v2/apps",
+				Requests:    5,
+				Concurrency: 1,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Requests).To(Equal(5))
+			Expect(result.Failures).To(Equal(5))
+		})
+
+		It("makes the requests as each of the run's users in turn", func() {
+			var (
+				mu    sync.Mutex
+				users = make(map[string]int)
+			)
+			server.RouteToHandler("GET", "/v3/apps", func(w http.ResponseWriter, req *http.Request) {
+				mu.Lock()
+				users[req.Header.Get("Authorization")]++
+				mu.Unlock()
+			})
+
+			result, err := runner.Run(context.Background(), logger, Run{
+				Path:         "/v3/apps",
+				Requests:     6,
+				Concurrency:  2,
+				Bucket:       "some-bucket",
+				TokenSources: []TokenSource{staticToken("bearer alice"), staticToken("bearer bob")},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(users).To(Equal(map[string]int{"bearer alice": 3, "bearer bob": 3}))
+			Expect(result.Bucket).To(Equal("some-bucket"))
+			Expect(result.Users).To(Equal(2))
+		})
+
+		It("gets a token for every request, so that expired tokens are refreshed", func() {
+			ts := &refreshingToken{}
+			runner.TokenSource = ts
+			server.RouteToHandler("GET", "/v3/apps", ghttp.RespondWith(200, "{}", nil))
+
+			_, err := runner.Run(context.Background(), logger, Run{
+				Path:        "/v3/apps",
+				Requests:    3,
+				Concurrency: 1,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ts.gets).To(Equal(4))
+		})
+
+		It("repeats a request with a new token when the token is rejected", func() {
+			ts := &refreshingToken{}
+			runner.TokenSource = ts
+			server.RouteToHandler("GET", "/v3/apps", func(w http.ResponseWriter, req *http.Request) {
+				if req.Header.Get("Authorization") != "bearer refreshed" {
+					w.WriteHeader(http.StatusUnauthorized)
+				}
+			})
+
+			result, err := runner.Run(context.Background(), logger, Run{
+				Path:        "/v3/apps",
+				Requests:    3,
+				Concurrency: 1,
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Requests).To(Equal(3))
+			Expect(result.Failures).To(BeZero())
+			Expect(ts.refreshes).To(Equal(1))
+		})
+	})
+
+	Describe("SummarizeLatencies", func() {
+		It("returns the nearest-rank percentiles", func() {
+			var latencies []time.Duration
+			for i := 100; i > 0; i-- {
+				latencies = append(latencies, time.Duration(i)*time.Millisecond)
+			}
+
+			summary := SummarizeLatencies(latencies)
+
+			Expect(summary.Min).To(Equal(1 * time.Millisecond))
+			Expect(summary.P50).To(Equal(50 * time.Millisecond))
+			Expect(summary.P90).To(Equal(90 * time.Millisecond))
+			Expect(summary.P99).To(Equal(99 * time.Millisecond))
+			Expect(summary.Max).To(Equal(100 * time.Millisecond))
+			Expect(summary.Mean).To(Equal(50500 * time.Microsecond))
+		})
+
+		It("returns an empty summary when there are no latencies", func() {
+			Expect(SummarizeLatencies(nil)).To(Equal(LatencySummary{}))
+		})
+	})
+})
+
+type staticToken string
+
+func (t staticToken) GetToken() (string, error) {
+	return string(t), nil
+}
+
+// refreshingToken counts the tokens it is asked for, and is rejected until it is refreshed
+type refreshingToken struct {
+	mu        sync.Mutex
+	gets      int
+	refreshes int
+}
+
+func (t *refreshingToken) GetToken() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.gets++
+	if t.refreshes > 0 {
+		return "bearer refreshed", nil
+	}
+	return "bearer expired", nil
+}
+
+func (t *refreshingToken) RefreshToken(rejected string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if rejected == "bearer expired" {
+		t.refreshes++
+	}
+	return "bearer refreshed", nil
+}
@@ -0,0 +1,135 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// AIMD limits the number of concurrent workers, adapting the limit to the health
+// of the server the workers make requests to
+//
+// The limit grows additively, by one for every limit's worth of healthy responses,
+// and is multiplied by Backoff when a response is unhealthy: an error such as a timeout,
+// a 5xx or 429 status code, or a latency above LatencyThreshold. After a decrease
+// further unhealthy responses are ignored for Cooldown, as they are likely to have been
+// caused by the same overload.
+type AIMD struct {
+	Min              int
+	Max              int
+	LatencyThreshold time.Duration
+	Backoff          float64
+	Cooldown         time.Duration
+
+	mu           sync.Mutex
+	limit        float64
+	healthy      int
+	inUse        int64
+	lastDecrease time.Time
+	changed      chan struct{}
+}
+
+// NewAIMD returns a limiter which starts at initial workers and stays within min and max
+func NewAIMD(initial int, min int, max int, latencyThreshold time.Duration) *AIMD {
+	return &AIMD{
+		Min:              min,
+		Max:              max,
+		LatencyThreshold: latencyThreshold,
+		Backoff:          0.5,
+		Cooldown:         latencyThreshold,
+
+		limit:   float64(initial),
+		changed: make(chan struct{}),
+	}
+}
+
+// Acquire blocks until n workers can start within the limit, or ctx is done
+func (l *AIMD) Acquire(ctx context.Context, n int64) error {
+	for {
+		l.mu.Lock()
+		if l.inUse+n <= l.currentLimit() {
+			l.inUse += n
+			l.mu.Unlock()
+			return nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Release marks n workers as finished
+func (l *AIMD) Release(n int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inUse -= n
+	l.notify()
+}
+
+// Observe adapts the limit to a response which took latency and was healthy or not
+func (l *AIMD) Observe(latency time.Duration, healthy bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if healthy && (l.LatencyThreshold <= 0 || latency <= l.LatencyThreshold) {
+		l.healthy++
+		if int64(l.healthy) < l.currentLimit() {
+			return
+		}
+
+		l.healthy = 0
+		if l.limit < float64(l.Max) {
+			l.limit = float64(l.currentLimit() + 1)
+			l.notify()
+		}
+		return
+	}
+
+	now := time.Now()
+	if now.Sub(l.lastDecrease) < l.Cooldown {
+		return
+	}
+	l.lastDecrease = now
+	l.healthy = 0
+
+	l.limit *= l.Backoff
+	if l.limit < float64(l.Min) {
+		l.limit = float64(l.Min)
+	}
+}
+
+// Limit returns the number of workers currently allowed
+func (l *AIMD) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return int(l.currentLimit())
+}
+
+// InUse returns the number of workers currently running
+func (l *AIMD) InUse() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return int(l.inUse)
+}
+
+func (l *AIMD) currentLimit() int64 {
+	limit := int64(l.limit)
+	if limit < 1 {
+		limit = 1
+	}
+	return limit
+}
+
+// notify wakes the workers waiting in Acquire, and must be called with mu held
+func (l *AIMD) notify() {
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
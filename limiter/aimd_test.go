@@ -0,0 +1,161 @@
+package limiter_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/pivotal-cf/perm-test/limiter"
+)
+
+var _ = Describe("AIMD", func() {
+	var l *AIMD
+
+	BeforeEach(func() {
+		l = NewAIMD(4, 1, 6, time.Second)
+		l.Cooldown = 0
+	})
+
+	It("grows by one after a limit's worth of healthy responses, up to the max", func() {
+		for i := 0; i < 4; i++ {
+			l.Observe(time.Millisecond, true)
+		}
+		Expect(l.Limit()).To(Equal(5))
+
+		for i := 0; i < 100; i++ {
+			l.Observe(time.Millisecond, true)
+		}
+		Expect(l.Limit()).To(Equal(6))
+	})
+
+	It("halves on unhealthy or slow responses, down to the min", func() {
+		l.Observe(time.Millisecond, false)
+		Expect(l.Limit()).To(Equal(2))
+
+		l.Observe(2*time.Second, true)
+		Expect(l.Limit()).To(Equal(1))
+
+		l.Observe(time.Millisecond, false)
+		Expect(l.Limit()).To(Equal(1))
+	})
+
+	It("ignores unhealthy responses during the cooldown after a decrease", func() {
+		l.Cooldown = time.Hour
+
+		l.Observe(time.Millisecond, false)
+		l.Observe(time.Millisecond, false)
+		Expect(l.Limit()).To(Equal(2))
+	})
+
+	Describe("Acquire", func() {
+		It("blocks once the limit is reached until a worker is released", func() {
+			for i := 0; i < 4; i++ {
+				Expect(l.Acquire(context.Background(), 1)).To(Succeed())
+			}
+			Expect(l.InUse()).To(Equal(4))
+
+			acquired := make(chan error)
+			go func() {
+				acquired <- l.Acquire(context.Background(), 1)
+			}()
+			Consistently(acquired).ShouldNot(Receive())
+
+			l.Release(1)
+			Eventually(acquired).Should(Receive(BeNil()))
+		})
+
+		It("unblocks when the limit grows", func() {
+			for i := 0; i < 4; i++ {
+				Expect(l.Acquire(context.Background(), 1)).To(Succeed())
+			}
+
+			acquired := make(chan error)
+			go func() {
+				acquired <- l.Acquire(context.Background(), 1)
+			}()
+
+			for i := 0; i < 4; i++ {
+				l.Observe(time.Millisecond, true)
+			}
+			Eventually(acquired).Should(Receive(BeNil()))
+		})
+
+		It("returns the context's error if it is done first", func() {
+			for i := 0; i < 4; i++ {
+				Expect(l.Acquire(context.Background(), 1)).To(Succeed())
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+
+			Expect(l.Acquire(ctx, 1)).To(Equal(context.Canceled))
+		})
+	})
+})
+
+var _ = Describe("Transport", func() {
+	var (
+		server   *ghttp.Server
+		observer *fakeObserver
+		client   *http.Client
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		observer = &fakeObserver{}
+		client = &http.Client{Transport: NewTransport(observer, nil)}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	get := func() {
+		resp, err := client.Get(server.URL())
+		if err == nil {
+			resp.Body.Close()
+		}
+	}
+
+	It("reports 2xx and 4xx responses as healthy", func() {
+		server.AppendHandlers(ghttp.RespondWith(200, ""), ghttp.RespondWith(404, ""))
+		get()
+		get()
+
+		Expect(observer.healthy).To(Equal([]bool{true, true}))
+	})
+
+	It("reports 5xx and 429 responses as unhealthy", func() {
+		server.AppendHandlers(ghttp.RespondWith(503, ""), ghttp.RespondWith(429, ""))
+		get()
+		get()
+
+		Expect(observer.healthy).To(Equal([]bool{false, false}))
+	})
+
+	It("reports errors as unhealthy", func() {
+		client.Transport = NewTransport(observer, failingTransport{})
+		get()
+
+		Expect(observer.healthy).To(Equal([]bool{false}))
+	})
+})
+
+type fakeObserver struct {
+	healthy []bool
+}
+
+func (o *fakeObserver) Observe(latency time.Duration, healthy bool) {
+	o.healthy = append(o.healthy, healthy)
+}
+
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, errors.New("timeout")
+}
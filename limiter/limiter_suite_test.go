@@ -0,0 +1,13 @@
+package limiter_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestLimiter(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Limiter Suite")
+}
@@ -0,0 +1,110 @@
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenBucket allows Rate events per second, in bursts of up to Burst
+//
+// Each call to Wait takes a token, reserving one from the future if none
+// are available and sleeping until it is due, so waiters are served in order.
+// A waiter whose context is done before its token is due gives the token back.
+type TokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a full bucket
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Wait blocks until a token is available, or returns ctx's error if ctx is done first
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens--
+	deficit := -b.tokens
+	b.mu.Unlock()
+
+	if deficit <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Duration(deficit / b.rate * float64(time.Second)))
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return ctx.Err()
+	}
+}
+
+// Rate limits requests to a total rate, and each operation to its own rate
+//
+// It satisfies cf.RateLimiter
+type Rate struct {
+	total      *TokenBucket
+	operations map[string]*TokenBucket
+}
+
+// NewRate returns a rate limiter allowing total requests per second and the
+// per-second rates in operations for each operation, in bursts of up to burst
+//
+// A total of 0 does not limit the total rate
+func NewRate(total float64, burst int, operations map[string]float64) *Rate {
+	r := &Rate{
+		operations: make(map[string]*TokenBucket),
+	}
+
+	if total > 0 {
+		r.total = NewTokenBucket(total, burst)
+	}
+	for op, rate := range operations {
+		r.operations[op] = NewTokenBucket(rate, burst)
+	}
+
+	return r
+}
+
+// Wait blocks until a request for the operation is within both its own rate and the total rate,
+// or returns ctx's error if ctx is done first
+func (r *Rate) Wait(ctx context.Context, operation string) error {
+	if b, ok := r.operations[operation]; ok {
+		err := b.Wait(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	if r.total != nil {
+		return r.total.Wait(ctx)
+	}
+	return nil
+}
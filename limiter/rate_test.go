@@ -0,0 +1,63 @@
+package limiter_test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/pivotal-cf/perm-test/limiter"
+)
+
+var _ = Describe("TokenBucket", func() {
+	It("allows a burst immediately and then paces waits to the rate", func() {
+		b := NewTokenBucket(100, 5)
+
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			Expect(b.Wait(context.Background())).To(Succeed())
+		}
+		Expect(time.Since(start)).To(BeNumerically("<", 10*time.Millisecond))
+
+		for i := 0; i < 10; i++ {
+			Expect(b.Wait(context.Background())).To(Succeed())
+		}
+		Expect(time.Since(start)).To(BeNumerically(">=", 90*time.Millisecond))
+	})
+
+	It("stops waiting when the context is done and gives the token back", func() {
+		b := NewTokenBucket(1, 1)
+		Expect(b.Wait(context.Background())).To(Succeed())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		Expect(b.Wait(ctx)).To(Equal(context.DeadlineExceeded))
+		Expect(time.Since(start)).To(BeNumerically("<", 500*time.Millisecond))
+
+		// the bucket is no further behind than before the cancelled wait
+		start = time.Now()
+		Expect(b.Wait(context.Background())).To(Succeed())
+		Expect(time.Since(start)).To(BeNumerically("<", 1100*time.Millisecond))
+	})
+})
+
+var _ = Describe("Rate", func() {
+	It("limits operations with their own rate as well as the total", func() {
+		r := NewRate(1000, 1, map[string]float64{"create_app": 50})
+
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			Expect(r.Wait(context.Background(), "create_org")).To(Succeed())
+		}
+		Expect(time.Since(start)).To(BeNumerically("<", 40*time.Millisecond))
+
+		start = time.Now()
+		for i := 0; i < 5; i++ {
+			Expect(r.Wait(context.Background(), "create_app")).To(Succeed())
+		}
+		Expect(time.Since(start)).To(BeNumerically(">=", 70*time.Millisecond))
+	})
+})
@@ -0,0 +1,41 @@
+package limiter
+
+import (
+	"net/http"
+	"time"
+)
+
+// Observer is told the latency and health of every response
+type Observer interface {
+	Observe(latency time.Duration, healthy bool)
+}
+
+// Transport reports every request made through it to an Observer
+//
+// Requests which error, including timeouts, or return a 5xx or 429 status code are unhealthy
+type Transport struct {
+	base     http.RoundTripper
+	observer Observer
+}
+
+// NewTransport wraps base, or http.DefaultTransport if base is nil
+func NewTransport(observer Observer, base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &Transport{
+		base:     base,
+		observer: observer,
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	healthy := err == nil && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests
+	t.observer.Observe(time.Since(start), healthy)
+
+	return resp, err
+}
@@ -0,0 +1,127 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+	"github.com/pivotal-cf/perm-test/cf/cffakes"
+	"github.com/pivotal-cf/perm-test/report"
+	"github.com/pivotal-cf/perm-test/retries"
+
+	. "github.com/pivotal-cf/perm-test/metrics"
+)
+
+var _ = Describe("Registry", func() {
+	var registry *Registry
+
+	BeforeEach(func() {
+		registry = NewRegistry()
+	})
+
+	scrape := func() string {
+		rec := httptest.NewRecorder()
+		registry.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+		Expect(rec.Header().Get("Content-Type")).To(HavePrefix("text/plain; version=0.0.4"))
+		return rec.Body.String()
+	}
+
+	It("writes counters and gauges in the text exposition format", func() {
+		c := registry.NewCounter("some_total", "Some help.", "type")
+		c.Inc("org")
+		c.Add(2, "space")
+		c.Inc("org")
+
+		g := registry.NewGauge("some_gauge", "Other help.")
+		g.Add(3)
+		g.Add(-1)
+
+		Expect(scrape()).To(Equal(`# HELP some_total Some help.
+# TYPE some_total counter
+some_total{type="org"} 2
+some_total{type="space"} 2
+# HELP some_gauge Other help.
+# TYPE some_gauge gauge
+some_gauge 2
+`))
+	})
+
+	It("writes cumulative histogram buckets", func() {
+		h := registry.NewHistogram("some_seconds", "Some help.", []float64{0.1, 1}, "code")
+		h.Observe(0.05, "200")
+		h.Observe(0.5, "200")
+		h.Observe(5, "200")
+
+		Expect(scrape()).To(Equal(`# HELP some_seconds Some help.
+# TYPE some_seconds histogram
+some_seconds_bucket{code="200",le="0.1"} 1
+some_seconds_bucket{code="200",le="1"} 2
+some_seconds_bucket{code="200",le="+Inf"} 3
+some_seconds_sum{code="200"} 5.55
+some_seconds_count{code="200"} 3
+`))
+	})
+
+	It("panics if the wrong number of label values are given", func() {
+		c := registry.NewCounter("some_total", "Some help.", "type")
+		Expect(func() { c.Inc() }).To(Panic())
+	})
+})
+
+var _ = Describe("SeederMetrics", func() {
+	It("counts operations, created resources, retries and backoff waits", func() {
+		registry := NewRegistry()
+		m := NewSeederMetrics(registry)
+
+		fakeSeeder := new(cffakes.FakeSeeder)
+		fakeSeeder.CreateOrgStub = func(ctx context.Context, logger lager.Logger, name string) (string, error) {
+			retries.Observe(ctx, time.Second)
+			retries.Observe(ctx, 500*time.Millisecond)
+			return "some-org-guid", nil
+		}
+		seeder := report.NewSeeder(fakeSeeder, m)
+
+		_, err := seeder.CreateOrg(context.Background(), lagertest.NewTestLogger("metrics"), "some-org")
+		Expect(err).NotTo(HaveOccurred())
+		m.ObserveCall(report.Call{Operation: report.OperationCreateOrg, Err: errors.New("boom")})
+
+		rec := httptest.NewRecorder()
+		registry.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		body := rec.Body.String()
+
+		Expect(body).To(ContainSubstring(`perm_test_seeder_operations_total{operation="create_org",result="failure"} 1`))
+		Expect(body).To(ContainSubstring(`perm_test_seeder_operations_total{operation="create_org",result="success"} 1`))
+		Expect(body).To(ContainSubstring(`perm_test_seeder_resources_created_total{type="org"} 1`))
+		Expect(body).To(ContainSubstring(`perm_test_seeder_retries_total{operation="create_org"} 2`))
+		Expect(body).To(ContainSubstring(`perm_test_seeder_backoff_wait_seconds_total{operation="create_org"} 1.5`))
+	})
+})
+
+var _ = Describe("Transport", func() {
+	It("records the response code of every request", func() {
+		server := ghttp.NewServer()
+		defer server.Close()
+		server.AppendHandlers(ghttp.RespondWith(201, "{}"))
+
+		registry := NewRegistry()
+		client := &http.Client{Transport: NewTransport(registry, "some_request_duration_seconds", nil)}
+
+		resp, err := client.Post(server.URL()+"/v2/organizations", "application/json", nil)
+		Expect(err).NotTo(HaveOccurred())
+		ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		rec := httptest.NewRecorder()
+		registry.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+		Expect(rec.Body.String()).To(ContainSubstring(`some_request_duration_seconds_count{method="POST",code="201"} 1`))
+	})
+})
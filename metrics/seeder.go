@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/pivotal-cf/perm-test/report"
+)
+
+// The resource type created by each seeding operation
+var createdResources = map[string]string{
+	report.OperationCreateOrg:   "org",
+	report.OperationCreateSpace: "space",
+	report.OperationCreateApp:   "app",
+	report.OperationCreateUser:  "user",
+}
+
+// SeederMetrics counts the calls made through a report.Seeder, and their retries
+type SeederMetrics struct {
+	operations       *Counter
+	resourcesCreated *Counter
+	retries          *Counter
+	backoffWait      *Counter
+	duration         *Histogram
+}
+
+func NewSeederMetrics(r *Registry) *SeederMetrics {
+	return &SeederMetrics{
+		operations:       r.NewCounter("perm_test_seeder_operations_total", "Seeding operations completed, by result.", "operation", "result"),
+		resourcesCreated: r.NewCounter("perm_test_seeder_resources_created_total", "Orgs, spaces, apps and users created or found to already exist.", "type"),
+		retries:          r.NewCounter("perm_test_seeder_retries_total", "Failed attempts which were retried.", "operation"),
+		backoffWait:      r.NewCounter("perm_test_seeder_backoff_wait_seconds_total", "Time spent waiting between retries.", "operation"),
+		duration:         r.NewHistogram("perm_test_seeder_operation_duration_seconds", "Duration of seeding operations, including retries.", DefaultBuckets, "operation"),
+	}
+}
+
+func (m *SeederMetrics) ObserveCall(c report.Call) {
+	result := "success"
+	if c.Err != nil {
+		result = "failure"
+	}
+	m.operations.Inc(c.Operation, result)
+
+	if t, ok := createdResources[c.Operation]; ok && c.Err == nil {
+		m.resourcesCreated.Inc(t)
+	}
+
+	m.duration.Observe(c.Latency.Seconds(), c.Operation)
+}
+
+// ObserveRetry counts each retry and the time waited before it as soon as it happens,
+// rather than once the call completes
+func (m *SeederMetrics) ObserveRetry(operation string, step time.Duration) {
+	m.retries.Inc(operation)
+	m.backoffWait.Add(step.Seconds(), operation)
+}
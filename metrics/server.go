@@ -0,0 +1,13 @@
+package metrics
+
+import (
+	"net/http"
+)
+
+// Serve exposes the registry on /metrics at addr (host:port) until the listener fails
+func Serve(addr string, r *Registry) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", r)
+
+	return http.ListenAndServe(addr, mux)
+}
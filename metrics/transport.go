@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Transport records the duration and response code of every request made through it
+type Transport struct {
+	base     http.RoundTripper
+	requests *Histogram
+}
+
+// NewTransport wraps base, or http.DefaultTransport if base is nil
+//
+// Requests are labelled by method and response code only, as paths contain GUIDs
+func NewTransport(r *Registry, name string, base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &Transport{
+		base:     base,
+		requests: r.NewHistogram(name, "Duration of HTTP requests, by method and response code.", DefaultBuckets, "method", "code"),
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	t.requests.Observe(time.Since(start).Seconds(), req.Method, code)
+
+	return resp, err
+}
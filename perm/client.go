@@ -0,0 +1,84 @@
+package perm
+
+import (
+	"context"
+	"crypto/tls"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// IsAlreadyExists returns true if Perm reported that the role or assignment already exists
+func IsAlreadyExists(err error) bool {
+	return code(err) == codes.AlreadyExists
+}
+
+// IsPermanent returns true if Perm rejected the call in a way which retrying cannot change:
+// the request is invalid, the client is not allowed to make it, or the server does not implement it.
+// Every other error, such as Unavailable while Perm restarts, is retried
+func IsPermanent(err error) bool {
+	switch code(err) {
+	case codes.InvalidArgument, codes.PermissionDenied, codes.Unimplemented:
+		return true
+	default:
+		return false
+	}
+}
+
+// code returns the gRPC status code of err, or codes.Unknown if it does not have one
+func code(err error) codes.Code {
+	if err == nil {
+		return codes.OK
+	}
+
+	s, ok := status.FromError(err)
+	if !ok {
+		return codes.Unknown
+	}
+	return s.Code()
+}
+
+// Client calls Perm's RoleService over a single gRPC connection
+type Client struct {
+	conn    *grpc.ClientConn
+	roles   RoleServiceClient
+	timeout time.Duration
+}
+
+// NewClient returns a client for the Perm server at addr (host:port) whose calls time out after timeout
+//
+// The connection is made in the background, so an unreachable server fails the first call rather than NewClient
+func NewClient(addr string, tlsConfig *tls.Config, timeout time.Duration) (*Client, error) {
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		conn:    conn,
+		roles:   NewRoleServiceClient(conn),
+		timeout: timeout,
+	}, nil
+}
+
+func (c *Client) CreateRole(ctx context.Context, req *CreateRoleRequest, opts ...grpc.CallOption) (*CreateRoleResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	return c.roles.CreateRole(ctx, req, opts...)
+}
+
+func (c *Client) AssignRole(ctx context.Context, req *AssignRoleRequest, opts ...grpc.CallOption) (*AssignRoleResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	return c.roles.AssignRole(ctx, req, opts...)
+}
+
+// Close closes the connection to Perm
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
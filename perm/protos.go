@@ -0,0 +1,165 @@
+package perm
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+// The messages and RoleService stubs below mirror the protos served by Perm
+// (package cloud_foundry.perm.protos) in the shape protoc-gen-go generates them,
+// declared by hand because Perm's generated protos are not vendored.
+
+type Actor struct {
+	ID        string `protobuf:"bytes,1,opt,name=ID,proto3"`
+	Namespace string `protobuf:"bytes,2,opt,name=namespace,proto3"`
+}
+
+func (m *Actor) Reset()         { *m = Actor{} }
+func (m *Actor) String() string { return proto.CompactTextString(m) }
+func (*Actor) ProtoMessage()    {}
+
+type Permission struct {
+	Name            string `protobuf:"bytes,1,opt,name=name,proto3"`
+	ResourcePattern string `protobuf:"bytes,2,opt,name=resourcePattern,proto3"`
+}
+
+func (m *Permission) Reset()         { *m = Permission{} }
+func (m *Permission) String() string { return proto.CompactTextString(m) }
+func (*Permission) ProtoMessage()    {}
+
+type Role struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3"`
+}
+
+func (m *Role) Reset()         { *m = Role{} }
+func (m *Role) String() string { return proto.CompactTextString(m) }
+func (*Role) ProtoMessage()    {}
+
+type CreateRoleRequest struct {
+	Name        string        `protobuf:"bytes,1,opt,name=name,proto3"`
+	Permissions []*Permission `protobuf:"bytes,2,rep,name=permissions"`
+}
+
+func (m *CreateRoleRequest) Reset()         { *m = CreateRoleRequest{} }
+func (m *CreateRoleRequest) String() string { return proto.CompactTextString(m) }
+func (*CreateRoleRequest) ProtoMessage()    {}
+
+type CreateRoleResponse struct {
+	Role *Role `protobuf:"bytes,1,opt,name=role"`
+}
+
+func (m *CreateRoleResponse) Reset()         { *m = CreateRoleResponse{} }
+func (m *CreateRoleResponse) String() string { return proto.CompactTextString(m) }
+func (*CreateRoleResponse) ProtoMessage()    {}
+
+type AssignRoleRequest struct {
+	Actor    *Actor `protobuf:"bytes,1,opt,name=actor"`
+	RoleName string `protobuf:"bytes,2,opt,name=roleName,proto3"`
+}
+
+func (m *AssignRoleRequest) Reset()         { *m = AssignRoleRequest{} }
+func (m *AssignRoleRequest) String() string { return proto.CompactTextString(m) }
+func (*AssignRoleRequest) ProtoMessage()    {}
+
+type AssignRoleResponse struct{}
+
+func (m *AssignRoleResponse) Reset()         { *m = AssignRoleResponse{} }
+func (m *AssignRoleResponse) String() string { return proto.CompactTextString(m) }
+func (*AssignRoleResponse) ProtoMessage()    {}
+
+// RoleServiceClient is the subset of Perm's RoleService used to seed role assignments
+type RoleServiceClient interface {
+	CreateRole(ctx context.Context, in *CreateRoleRequest, opts ...grpc.CallOption) (*CreateRoleResponse, error)
+	AssignRole(ctx context.Context, in *AssignRoleRequest, opts ...grpc.CallOption) (*AssignRoleResponse, error)
+}
+
+type roleServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewRoleServiceClient(cc *grpc.ClientConn) RoleServiceClient {
+	return &roleServiceClient{cc}
+}
+
+func (c *roleServiceClient) CreateRole(ctx context.Context, in *CreateRoleRequest, opts ...grpc.CallOption) (*CreateRoleResponse, error) {
+	out := new(CreateRoleResponse)
+	err := grpc.Invoke(ctx, "/cloud_foundry.perm.protos.RoleService/CreateRole", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *roleServiceClient) AssignRole(ctx context.Context, in *AssignRoleRequest, opts ...grpc.CallOption) (*AssignRoleResponse, error) {
+	out := new(AssignRoleResponse)
+	err := grpc.Invoke(ctx, "/cloud_foundry.perm.protos.RoleService/AssignRole", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// RoleServiceServer is the server API for the subset of Perm's RoleService used to seed role assignments
+type RoleServiceServer interface {
+	CreateRole(context.Context, *CreateRoleRequest) (*CreateRoleResponse, error)
+	AssignRole(context.Context, *AssignRoleRequest) (*AssignRoleResponse, error)
+}
+
+func RegisterRoleServiceServer(s *grpc.Server, srv RoleServiceServer) {
+	s.RegisterService(&_RoleService_serviceDesc, srv)
+}
+
+func _RoleService_CreateRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateRoleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoleServiceServer).CreateRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cloud_foundry.perm.protos.RoleService/CreateRole",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoleServiceServer).CreateRole(ctx, req.(*CreateRoleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RoleService_AssignRole_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AssignRoleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RoleServiceServer).AssignRole(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/cloud_foundry.perm.protos.RoleService/AssignRole",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RoleServiceServer).AssignRole(ctx, req.(*AssignRoleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _RoleService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "cloud_foundry.perm.protos.RoleService",
+	HandlerType: (*RoleServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateRole",
+			Handler:    _RoleService_CreateRole_Handler,
+		},
+		{
+			MethodName: "AssignRole",
+			Handler:    _RoleService_AssignRole_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "protos/role_service.proto",
+}
@@ -0,0 +1,255 @@
+package perm
+
+import (
+	"context"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/cenkalti/backoff"
+	"github.com/pivotal-cf/perm-test/retries"
+	"github.com/satori/go.uuid"
+)
+
+// roleType is a kind of CF role, which Perm represents as a role per org or space
+type roleType struct {
+	prefix     string
+	permission string
+}
+
+var (
+	orgUser           = roleType{"org-user", "org.user"}
+	orgManager        = roleType{"org-manager", "org.manager"}
+	orgBillingManager = roleType{"org-billing-manager", "org.billing_manager"}
+	orgAuditor        = roleType{"org-auditor", "org.auditor"}
+
+	spaceDeveloper = roleType{"space-developer", "space.developer"}
+	spaceManager   = roleType{"space-manager", "space.manager"}
+	spaceAuditor   = roleType{"space-auditor", "space.auditor"}
+
+	orgRoleTypes   = []roleType{orgUser, orgManager, orgBillingManager, orgAuditor}
+	spaceRoleTypes = []roleType{spaceDeveloper, spaceManager, spaceAuditor}
+)
+
+func (t roleType) roleName(guid string) string {
+	return t.prefix + "-" + guid
+}
+
+// namespace for the GUIDs of orgs and spaces which only exist in Perm
+var resourceNamespace = uuid.NewV5(uuid.NamespaceURL, "https://github.com/pivotal-cf/perm-test")
+
+// Seeder seeds role assignments directly into Perm, bypassing Cloud Controller
+//
+// Orgs and spaces are not created anywhere. Each is given a GUID derived from
+// its name and a role in Perm for every CF role type granting access to it,
+// which users are then assigned.
+// Apps and users have no representation in Perm, so creating them does nothing.
+type Seeder struct {
+	// BackOff returns the backoff each call is retried with, defaulting to backoff.NewExponentialBackOff
+	BackOff func() backoff.BackOff
+
+	client         RoleServiceClient
+	actorNamespace string
+}
+
+func NewSeeder(client RoleServiceClient, actorNamespace string) *Seeder {
+	return &Seeder{
+		client:         client,
+		actorNamespace: actorNamespace,
+	}
+}
+
+// ResourceGUID returns the GUID given to the org or space with the name
+func ResourceGUID(name string) string {
+	return uuid.NewV5(resourceNamespace, name).String()
+}
+
+// OrgUserRoleName returns the name of the role granting org user access to the org
+func OrgUserRoleName(orgGUID string) string {
+	return orgUser.roleName(orgGUID)
+}
+
+// SpaceDeveloperRoleName returns the name of the role granting space developer access to the space
+func SpaceDeveloperRoleName(spaceGUID string) string {
+	return spaceDeveloper.roleName(spaceGUID)
+}
+
+func (s *Seeder) CreateOrg(ctx context.Context, logger lager.Logger, name string) (string, error) {
+	guid := ResourceGUID(name)
+
+	logger = logger.Session("create-org", lager.Data{
+		"name": name,
+		"guid": guid,
+	})
+	for _, t := range orgRoleTypes {
+		err := s.createRole(ctx, logger, t.roleName(guid), t.permission, guid)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return guid, nil
+}
+
+func (s *Seeder) CreateSpace(ctx context.Context, logger lager.Logger, name string, orgGUID string) (string, error) {
+	guid := ResourceGUID(name)
+
+	logger = logger.Session("create-space", lager.Data{
+		"name": name,
+		"guid": guid,
+	})
+	for _, t := range spaceRoleTypes {
+		err := s.createRole(ctx, logger, t.roleName(guid), t.permission, guid)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return guid, nil
+}
+
+func (s *Seeder) CreateApp(ctx context.Context, logger lager.Logger, name string, spaceGUID string) (string, error) {
+	return "", nil
+}
+
+func (s *Seeder) CreateUser(ctx context.Context, logger lager.Logger, userGUID string) error {
+	return nil
+}
+
+func (s *Seeder) AssociateUserWithOrg(ctx context.Context, logger lager.Logger, userGUID string, orgGUID string) error {
+	return s.assignOrgRole(ctx, logger.Session("associate-user-with-org"), orgUser, userGUID, orgGUID)
+}
+
+func (s *Seeder) AssociateOrgManager(ctx context.Context, logger lager.Logger, userGUID string, orgGUID string) error {
+	return s.assignOrgRole(ctx, logger.Session("associate-org-manager"), orgManager, userGUID, orgGUID)
+}
+
+func (s *Seeder) AssociateOrgBillingManager(ctx context.Context, logger lager.Logger, userGUID string, orgGUID string) error {
+	return s.assignOrgRole(ctx, logger.Session("associate-org-billing-manager"), orgBillingManager, userGUID, orgGUID)
+}
+
+func (s *Seeder) AssociateOrgAuditor(ctx context.Context, logger lager.Logger, userGUID string, orgGUID string) error {
+	return s.assignOrgRole(ctx, logger.Session("associate-org-auditor"), orgAuditor, userGUID, orgGUID)
+}
+
+func (s *Seeder) MakeUserSpaceDeveloper(ctx context.Context, logger lager.Logger, userGUID string, spaceGUID string) error {
+	return s.assignSpaceRole(ctx, logger.Session("make-user-space-developer"), spaceDeveloper, userGUID, spaceGUID)
+}
+
+func (s *Seeder) MakeUserSpaceManager(ctx context.Context, logger lager.Logger, userGUID string, spaceGUID string) error {
+	return s.assignSpaceRole(ctx, logger.Session("make-user-space-manager"), spaceManager, userGUID, spaceGUID)
+}
+
+func (s *Seeder) MakeUserSpaceAuditor(ctx context.Context, logger lager.Logger, userGUID string, spaceGUID string) error {
+	return s.assignSpaceRole(ctx, logger.Session("make-user-space-auditor"), spaceAuditor, userGUID, spaceGUID)
+}
+
+func (s *Seeder) assignOrgRole(ctx context.Context, logger lager.Logger, t roleType, userGUID string, orgGUID string) error {
+	return s.assignRole(ctx, logger.WithData(lager.Data{
+		"user-guid": userGUID,
+		"org-guid":  orgGUID,
+	}), userGUID, t.roleName(orgGUID))
+}
+
+func (s *Seeder) assignSpaceRole(ctx context.Context, logger lager.Logger, t roleType, userGUID string, spaceGUID string) error {
+	return s.assignRole(ctx, logger.WithData(lager.Data{
+		"user-guid":  userGUID,
+		"space-guid": spaceGUID,
+	}), userGUID, t.roleName(spaceGUID))
+}
+
+func (s *Seeder) newBackOff(ctx context.Context) backoff.BackOff {
+	if s.BackOff == nil {
+		return backoff.WithContext(backoff.NewExponentialBackOff(), ctx)
+	}
+	return backoff.WithContext(s.BackOff(), ctx)
+}
+
+// createRole creates the role using the seeder's backoff strategy,
+// returning early if it successfully creates the role or the role already exists,
+// or without retrying if Perm rejects the call permanently
+//
+// Retrying stops once ctx is done, which also cancels an attempt in flight
+func (s *Seeder) createRole(ctx context.Context, logger lager.Logger, roleName string, permission string, resourcePattern string) error {
+	logger.Debug("creating-role", lager.Data{
+		"role": roleName,
+	})
+
+	req := &CreateRoleRequest{
+		Name: roleName,
+		Permissions: []*Permission{
+			{
+				Name:            permission,
+				ResourcePattern: resourcePattern,
+			},
+		},
+	}
+
+	operation := func() error {
+		_, err := s.client.CreateRole(ctx, req)
+		if IsAlreadyExists(err) {
+			logger.Debug("role-already-exists")
+			return nil
+		}
+		if IsPermanent(err) {
+			return backoff.Permanent(err)
+		}
+
+		return err
+	}
+
+	err := backoff.RetryNotify(operation, s.newBackOff(ctx), retries.Notify(ctx, func(err error, step time.Duration) {
+		logger.Error("failed-to-create-role", err, lager.Data{
+			"backoff.step": step.String(),
+		})
+	}))
+	if err != nil {
+		logger.Error("finally-failed-to-create-role", err)
+		return err
+	}
+
+	return nil
+}
+
+// assignRole assigns the role to the user using the seeder's backoff strategy,
+// returning early if it successfully assigns the role or the user already has it,
+// or without retrying if Perm rejects the call permanently
+//
+// Retrying stops once ctx is done, which also cancels an attempt in flight
+func (s *Seeder) assignRole(ctx context.Context, logger lager.Logger, userGUID string, roleName string) error {
+	logger.Debug("assigning-role", lager.Data{
+		"role": roleName,
+	})
+
+	req := &AssignRoleRequest{
+		Actor: &Actor{
+			ID:        userGUID,
+			Namespace: s.actorNamespace,
+		},
+		RoleName: roleName,
+	}
+
+	operation := func() error {
+		_, err := s.client.AssignRole(ctx, req)
+		if IsAlreadyExists(err) {
+			logger.Debug("role-already-assigned")
+			return nil
+		}
+		if IsPermanent(err) {
+			return backoff.Permanent(err)
+		}
+
+		return err
+	}
+
+	err := backoff.RetryNotify(operation, s.newBackOff(ctx), retries.Notify(ctx, func(err error, step time.Duration) {
+		logger.Error("failed-to-assign-role", err, lager.Data{
+			"backoff.step": step.String(),
+		})
+	}))
+	if err != nil {
+		logger.Error("finally-failed-to-assign-role", err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package perm_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/cenkalti/backoff"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	. "github.com/pivotal-cf/perm-test/perm"
+)
+
+// fakeRoleService implements the parts of Perm's RoleService used by the seeder
+type fakeRoleService struct {
+	mu          sync.Mutex
+	roles       map[string]*CreateRoleRequest
+	assignments []*AssignRoleRequest
+
+	// assignCode, if not OK, is returned from every AssignRole call
+	assignCode codes.Code
+
+	// unavailable is the number of AssignRole calls still to fail as if Perm were restarting
+	unavailable int
+}
+
+func (f *fakeRoleService) CreateRole(ctx context.Context, req *CreateRoleRequest) (*CreateRoleResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.roles[req.Name]; ok {
+		return nil, status.Error(codes.AlreadyExists, "role already exists")
+	}
+	f.roles[req.Name] = req
+
+	return &CreateRoleResponse{Role: &Role{Name: req.Name}}, nil
+}
+
+func (f *fakeRoleService) AssignRole(ctx context.Context, req *AssignRoleRequest) (*AssignRoleResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.assignments = append(f.assignments, req)
+	if f.unavailable > 0 {
+		f.unavailable--
+		return nil, status.Error(codes.Unavailable, "perm is restarting")
+	}
+	if f.assignCode != codes.OK {
+		return nil, status.Error(f.assignCode, "assignment rejected")
+	}
+
+	return &AssignRoleResponse{}, nil
+}
+
+var _ = Describe("Seeder", func() {
+	var (
+		fake   *fakeRoleService
+		server *grpc.Server
+		client *Client
+
+		seeder *Seeder
+		logger *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		fake = &fakeRoleService{
+			roles: make(map[string]*CreateRoleRequest),
+		}
+
+		// borrow httptest's certificate for 127.0.0.1
+		certServer := httptest.NewUnstartedServer(http.NotFoundHandler())
+		certServer.StartTLS()
+		cert := certServer.TLS.Certificates[0]
+		pool := x509.NewCertPool()
+		pool.AddCert(certServer.Certificate())
+		certServer.Close()
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+
+		server = grpc.NewServer(grpc.Creds(credentials.NewServerTLSFromCert(&cert)))
+		RegisterRoleServiceServer(server, fake)
+		go server.Serve(listener)
+
+		client, err = NewClient(listener.Addr().String(), &tls.Config{
+			RootCAs: pool,
+		}, time.Second)
+		Expect(err).NotTo(HaveOccurred())
+
+		seeder = NewSeeder(client, "https://uaa.example.com/oauth/token")
+		logger = lagertest.NewTestLogger("perm-seeder")
+	})
+
+	AfterEach(func() {
+		client.Close()
+		server.Stop()
+	})
+
+	It("creates a role for each org role type", func() {
+		guid, err := seeder.CreateOrg(context.Background(), logger, "some-org")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(guid).To(Equal(ResourceGUID("some-org")))
+		Expect(fake.roles).To(HaveKey("org-manager-" + guid))
+		Expect(fake.roles).To(HaveKey("org-billing-manager-" + guid))
+		Expect(fake.roles).To(HaveKey("org-auditor-" + guid))
+
+		role := fake.roles[OrgUserRoleName(guid)]
+		Expect(role).NotTo(BeNil())
+		Expect(role.Permissions).To(HaveLen(1))
+		Expect(role.Permissions[0].Name).To(Equal("org.user"))
+		Expect(role.Permissions[0].ResourcePattern).To(Equal(guid))
+	})
+
+	It("creates a role for each space role type", func() {
+		guid, err := seeder.CreateSpace(context.Background(), logger, "some-space", "some-org-guid")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fake.roles).To(HaveLen(3))
+		Expect(fake.roles["space-auditor-"+guid].Permissions[0].Name).To(Equal("space.auditor"))
+
+		role := fake.roles[SpaceDeveloperRoleName(guid)]
+		Expect(role).NotTo(BeNil())
+		Expect(role.Permissions[0].Name).To(Equal("space.developer"))
+		Expect(role.Permissions[0].ResourcePattern).To(Equal(guid))
+	})
+
+	It("succeeds when the role already exists", func() {
+		first, err := seeder.CreateOrg(context.Background(), logger, "some-org")
+		Expect(err).NotTo(HaveOccurred())
+
+		second, err := seeder.CreateOrg(context.Background(), logger, "some-org")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(second).To(Equal(first))
+		Expect(fake.roles).To(HaveLen(4))
+	})
+
+	It("assigns the org and space roles to the user", func() {
+		err := seeder.AssociateUserWithOrg(context.Background(), logger, "some-user-guid", "some-org-guid")
+		Expect(err).NotTo(HaveOccurred())
+
+		err = seeder.MakeUserSpaceDeveloper(context.Background(), logger, "some-user-guid", "some-space-guid")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fake.assignments).To(HaveLen(2))
+
+		Expect(fake.assignments[0].Actor.ID).To(Equal("some-user-guid"))
+		Expect(fake.assignments[0].Actor.Namespace).To(Equal("https://uaa.example.com/oauth/token"))
+		Expect(fake.assignments[0].RoleName).To(Equal(OrgUserRoleName("some-org-guid")))
+
+		Expect(fake.assignments[1].RoleName).To(Equal(SpaceDeveloperRoleName("some-space-guid")))
+	})
+
+	It("assigns the other org and space role types", func() {
+		Expect(seeder.AssociateOrgBillingManager(context.Background(), logger, "some-user-guid", "some-org-guid")).To(Succeed())
+		Expect(seeder.MakeUserSpaceAuditor(context.Background(), logger, "some-user-guid", "some-space-guid")).To(Succeed())
+
+		Expect(fake.assignments).To(HaveLen(2))
+		Expect(fake.assignments[0].RoleName).To(Equal("org-billing-manager-some-org-guid"))
+		Expect(fake.assignments[1].RoleName).To(Equal("space-auditor-some-space-guid"))
+	})
+
+	It("does not retry calls which Perm rejects permanently", func() {
+		fake.assignCode = codes.PermissionDenied
+
+		err := seeder.AssociateUserWithOrg(context.Background(), logger, "some-user-guid", "some-org-guid")
+		Expect(IsPermanent(err)).To(BeTrue())
+
+		Expect(fake.assignments).To(HaveLen(1))
+	})
+
+	It("retries calls while Perm is unavailable", func() {
+		fake.unavailable = 2
+		seeder.BackOff = func() backoff.BackOff {
+			return backoff.NewConstantBackOff(time.Millisecond)
+		}
+
+		err := seeder.AssociateUserWithOrg(context.Background(), logger, "some-user-guid", "some-org-guid")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fake.assignments).To(HaveLen(3))
+	})
+
+	It("does nothing for apps and users", func() {
+		guid, err := seeder.CreateApp(context.Background(), logger, "some-app", "some-space-guid")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(guid).To(BeEmpty())
+		Expect(seeder.CreateUser(context.Background(), logger, "some-user-guid")).To(Succeed())
+
+		Expect(fake.roles).To(BeEmpty())
+		Expect(fake.assignments).To(BeEmpty())
+	})
+})
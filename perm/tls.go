@@ -0,0 +1,25 @@
+package perm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+)
+
+// NewTLSConfig returns a TLS config trusting the PEM-encoded CA certificate at caCertPath
+func NewTLSConfig(caCertPath string) (*tls.Config, error) {
+	caCert, err := ioutil.ReadFile(caCertPath)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, errors.New("perm: no certificates found in CA cert")
+	}
+
+	return &tls.Config{
+		RootCAs: pool,
+	}, nil
+}
@@ -0,0 +1,53 @@
+package report
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	"github.com/pivotal-cf/perm-test/experiment"
+)
+
+// Document is the JSON results of a loaddata or runexperiment run
+//
+// Config is the config which produced the run, with any secrets redacted
+type Document struct {
+	Command     string                    `json:"command"`
+	StartedAt   time.Time                 `json:"started_at"`
+	FinishedAt  time.Time                 `json:"finished_at"`
+	Config      interface{}               `json:"config"`
+	Phases      []Phase                   `json:"phases"`
+	Operations  map[string]OperationStats `json:"operations,omitempty"`
+	Experiments []*experiment.Result      `json:"experiments,omitempty"`
+	Failures    []Failure                 `json:"failures,omitempty"`
+}
+
+// Failure is a resource which could not be seeded, and the error which stopped it
+//
+// Type is the kind of resource, as in the seeding manifest, e.g. org or user
+type Failure struct {
+	Type  string `json:"type"`
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// NewDocument returns a document containing everything recorded by the recorder
+func NewDocument(command string, config interface{}, startedAt time.Time, r *Recorder) *Document {
+	return &Document{
+		Command:    command,
+		StartedAt:  startedAt.UTC(),
+		FinishedAt: time.Now().UTC(),
+		Config:     config,
+		Phases:     r.Phases(),
+		Operations: r.Operations(),
+	}
+}
+
+func (d *Document) WriteFile(path string) error {
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
@@ -0,0 +1,128 @@
+package report
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pivotal-cf/perm-test/experiment"
+)
+
+type OperationStats struct {
+	Count     int                          `json:"count"`
+	Retries   int                          `json:"retries"`
+	Failures  int                          `json:"failures"`
+	Latencies experiment.LatencySummary    `json:"latencies"`
+	Histogram []experiment.HistogramBucket `json:"histogram"`
+}
+
+type Phase struct {
+	Name      string        `json:"name"`
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+}
+
+type operation struct {
+	retries   int
+	failures  int
+	latencies []time.Duration
+}
+
+// Recorder collects the latency, retries and outcome of every operation, and
+// the duration of each phase of a run
+//
+// A nil *Recorder discards everything recorded, so recording is optional
+type Recorder struct {
+	mu         sync.Mutex
+	operations map[string]*operation
+	phases     []Phase
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		operations: make(map[string]*operation),
+	}
+}
+
+// Record records a single call of the named operation
+//
+// latency includes the time spent retrying, and retries is the number of
+// attempts which failed before the call succeeded or gave up
+func (r *Recorder) Record(name string, latency time.Duration, retries int, err error) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	op, ok := r.operations[name]
+	if !ok {
+		op = &operation{}
+		r.operations[name] = op
+	}
+
+	op.latencies = append(op.latencies, latency)
+	op.retries += retries
+	if err != nil {
+		op.failures++
+	}
+}
+
+// ObserveCall records a call made through a Seeder
+func (r *Recorder) ObserveCall(c Call) {
+	r.Record(c.Operation, c.Latency, c.Retries, c.Err)
+}
+
+// StartPhase starts timing the named phase, returning a function which ends it
+func (r *Recorder) StartPhase(name string) func() {
+	start := time.Now()
+
+	return func() {
+		if r == nil {
+			return
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		r.phases = append(r.phases, Phase{
+			Name:      name,
+			StartedAt: start.UTC(),
+			Duration:  time.Since(start),
+		})
+	}
+}
+
+// Operations returns the statistics of every operation recorded, by name
+func (r *Recorder) Operations() map[string]OperationStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := make(map[string]OperationStats)
+	for name, op := range r.operations {
+		stats[name] = OperationStats{
+			Count:     len(op.latencies),
+			Retries:   op.retries,
+			Failures:  op.failures,
+			Latencies: experiment.SummarizeLatencies(op.latencies),
+			Histogram: experiment.Histogram(op.latencies),
+		}
+	}
+
+	return stats
+}
+
+// Phases returns every phase which has ended, in the order they started
+func (r *Recorder) Phases() []Phase {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	phases := make([]Phase, len(r.phases))
+	copy(phases, r.phases)
+	sort.SliceStable(phases, func(i, j int) bool {
+		return phases[i].StartedAt.Before(phases[j].StartedAt)
+	})
+
+	return phases
+}
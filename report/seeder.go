@@ -0,0 +1,173 @@
+package report
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/pivotal-cf/perm-test/cf"
+	"github.com/pivotal-cf/perm-test/retries"
+)
+
+// Names of the seeding operations in the report
+const (
+	OperationCreateOrg                  = cf.OperationCreateOrg
+	OperationCreateSpace                = cf.OperationCreateSpace
+	OperationCreateApp                  = cf.OperationCreateApp
+	OperationCreateUser                 = cf.OperationCreateUser
+	OperationAssociateUserWithOrg       = cf.OperationAssociateUserWithOrg
+	OperationAssociateOrgManager        = cf.OperationAssociateOrgManager
+	OperationAssociateOrgBillingManager = cf.OperationAssociateOrgBillingManager
+	OperationAssociateOrgAuditor        = cf.OperationAssociateOrgAuditor
+	OperationMakeUserSpaceDeveloper     = cf.OperationMakeUserSpaceDeveloper
+	OperationMakeUserSpaceManager       = cf.OperationMakeUserSpaceManager
+	OperationMakeUserSpaceAuditor       = cf.OperationMakeUserSpaceAuditor
+)
+
+// Call is a single completed call to a seeder
+type Call struct {
+	Operation string
+
+	// Latency includes the time spent retrying
+	Latency time.Duration
+
+	// Retries is the number of attempts which failed before the call succeeded or gave up,
+	// and BackoffWait the total time waited between them, as reported to the call's retries.Observer
+	Retries     int
+	BackoffWait time.Duration
+
+	Err error
+}
+
+// Observer is notified of every call made through a Seeder
+type Observer interface {
+	ObserveCall(Call)
+}
+
+// RetryObserver is an Observer which is also notified of each retry as it happens,
+// with the time waited before it
+type RetryObserver interface {
+	ObserveRetry(operation string, step time.Duration)
+}
+
+// Seeder records the latency, retries and outcome of every call to the seeder it wraps
+type Seeder struct {
+	seeder    cf.Seeder
+	observers []Observer
+}
+
+func NewSeeder(seeder cf.Seeder, observers ...Observer) *Seeder {
+	return &Seeder{
+		seeder:    seeder,
+		observers: observers,
+	}
+}
+
+func (s *Seeder) CreateOrg(ctx context.Context, logger lager.Logger, name string) (string, error) {
+	var guid string
+	err := s.record(ctx, OperationCreateOrg, func(ctx context.Context) error {
+		var err error
+		guid, err = s.seeder.CreateOrg(ctx, logger, name)
+		return err
+	})
+	return guid, err
+}
+
+func (s *Seeder) CreateSpace(ctx context.Context, logger lager.Logger, name string, orgGUID string) (string, error) {
+	var guid string
+	err := s.record(ctx, OperationCreateSpace, func(ctx context.Context) error {
+		var err error
+		guid, err = s.seeder.CreateSpace(ctx, logger, name, orgGUID)
+		return err
+	})
+	return guid, err
+}
+
+func (s *Seeder) CreateApp(ctx context.Context, logger lager.Logger, name string, spaceGUID string) (string, error) {
+	var guid string
+	err := s.record(ctx, OperationCreateApp, func(ctx context.Context) error {
+		var err error
+		guid, err = s.seeder.CreateApp(ctx, logger, name, spaceGUID)
+		return err
+	})
+	return guid, err
+}
+
+func (s *Seeder) CreateUser(ctx context.Context, logger lager.Logger, userGUID string) error {
+	return s.record(ctx, OperationCreateUser, func(ctx context.Context) error {
+		return s.seeder.CreateUser(ctx, logger, userGUID)
+	})
+}
+
+func (s *Seeder) AssociateUserWithOrg(ctx context.Context, logger lager.Logger, userGUID string, orgGUID string) error {
+	return s.record(ctx, OperationAssociateUserWithOrg, func(ctx context.Context) error {
+		return s.seeder.AssociateUserWithOrg(ctx, logger, userGUID, orgGUID)
+	})
+}
+
+func (s *Seeder) AssociateOrgManager(ctx context.Context, logger lager.Logger, userGUID string, orgGUID string) error {
+	return s.record(ctx, OperationAssociateOrgManager, func(ctx context.Context) error {
+		return s.seeder.AssociateOrgManager(ctx, logger, userGUID, orgGUID)
+	})
+}
+
+func (s *Seeder) AssociateOrgBillingManager(ctx context.Context, logger lager.Logger, userGUID string, orgGUID string) error {
+	return s.record(ctx, OperationAssociateOrgBillingManager, func(ctx context.Context) error {
+		return s.seeder.AssociateOrgBillingManager(ctx, logger, userGUID, orgGUID)
+	})
+}
+
+func (s *Seeder) AssociateOrgAuditor(ctx context.Context, logger lager.Logger, userGUID string, orgGUID string) error {
+	return s.record(ctx, OperationAssociateOrgAuditor, func(ctx context.Context) error {
+		return s.seeder.AssociateOrgAuditor(ctx, logger, userGUID, orgGUID)
+	})
+}
+
+func (s *Seeder) MakeUserSpaceDeveloper(ctx context.Context, logger lager.Logger, userGUID string, spaceGUID string) error {
+	return s.record(ctx, OperationMakeUserSpaceDeveloper, func(ctx context.Context) error {
+		return s.seeder.MakeUserSpaceDeveloper(ctx, logger, userGUID, spaceGUID)
+	})
+}
+
+func (s *Seeder) MakeUserSpaceManager(ctx context.Context, logger lager.Logger, userGUID string, spaceGUID string) error {
+	return s.record(ctx, OperationMakeUserSpaceManager, func(ctx context.Context) error {
+		return s.seeder.MakeUserSpaceManager(ctx, logger, userGUID, spaceGUID)
+	})
+}
+
+func (s *Seeder) MakeUserSpaceAuditor(ctx context.Context, logger lager.Logger, userGUID string, spaceGUID string) error {
+	return s.record(ctx, OperationMakeUserSpaceAuditor, func(ctx context.Context) error {
+		return s.seeder.MakeUserSpaceAuditor(ctx, logger, userGUID, spaceGUID)
+	})
+}
+
+func (s *Seeder) record(ctx context.Context, name string, call func(context.Context) error) error {
+	var count, wait int64
+	ctx = retries.WithObserver(ctx, func(step time.Duration) {
+		atomic.AddInt64(&count, 1)
+		atomic.AddInt64(&wait, int64(step))
+
+		for _, o := range s.observers {
+			if r, ok := o.(RetryObserver); ok {
+				r.ObserveRetry(name, step)
+			}
+		}
+	})
+
+	start := time.Now()
+	err := call(ctx)
+
+	c := Call{
+		Operation:   name,
+		Latency:     time.Since(start),
+		Retries:     int(atomic.LoadInt64(&count)),
+		BackoffWait: time.Duration(atomic.LoadInt64(&wait)),
+		Err:         err,
+	}
+	for _, o := range s.observers {
+		o.ObserveCall(c)
+	}
+
+	return err
+}
@@ -0,0 +1,91 @@
+package report_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"code.cloudfoundry.org/lager/lagertest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/pivotal-cf/perm-test/cf/cffakes"
+	"github.com/pivotal-cf/perm-test/retries"
+
+	. "github.com/pivotal-cf/perm-test/report"
+)
+
+var _ = Describe("Seeder", func() {
+	var (
+		fakeSeeder *cffakes.FakeSeeder
+		recorder   *Recorder
+		seeder     *Seeder
+
+		logger *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		fakeSeeder = new(cffakes.FakeSeeder)
+		recorder = NewRecorder()
+		seeder = NewSeeder(fakeSeeder, recorder)
+
+		logger = lagertest.NewTestLogger("report")
+	})
+
+	It("records each call with its latency and delegates to the wrapped seeder", func() {
+		fakeSeeder.CreateOrgReturns("some-org-guid", nil)
+
+		guid, err := seeder.CreateOrg(context.Background(), logger, "some-org")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(guid).To(Equal("some-org-guid"))
+
+		_, err = seeder.CreateOrg(context.Background(), logger, "other-org")
+		Expect(err).NotTo(HaveOccurred())
+
+		stats := recorder.Operations()
+		Expect(stats).To(HaveKey(OperationCreateOrg))
+		Expect(stats[OperationCreateOrg].Count).To(Equal(2))
+		Expect(stats[OperationCreateOrg].Failures).To(BeZero())
+	})
+
+	It("counts the retries made while the call was made", func() {
+		fakeSeeder.MakeUserSpaceDeveloperStub = func(ctx context.Context, logger lager.Logger, userGUID string, spaceGUID string) error {
+			retries.Observe(ctx, time.Second)
+			retries.Observe(ctx, 2*time.Second)
+			return errors.New("boom")
+		}
+
+		err := seeder.MakeUserSpaceDeveloper(context.Background(), logger, "some-user-guid", "some-space-guid")
+		Expect(err).To(MatchError("boom"))
+
+		stats := recorder.Operations()[OperationMakeUserSpaceDeveloper]
+		Expect(stats.Count).To(Equal(1))
+		Expect(stats.Retries).To(Equal(2))
+		Expect(stats.Failures).To(Equal(1))
+	})
+})
+
+var _ = Describe("Recorder", func() {
+	It("records phases in the order they started", func() {
+		recorder := NewRecorder()
+
+		endFirst := recorder.StartPhase("first")
+		time.Sleep(time.Millisecond)
+		endSecond := recorder.StartPhase("second")
+		endSecond()
+		endFirst()
+
+		phases := recorder.Phases()
+		Expect(phases).To(HaveLen(2))
+		Expect(phases[0].Name).To(Equal("first"))
+		Expect(phases[1].Name).To(Equal("second"))
+		Expect(phases[0].Duration).To(BeNumerically(">", phases[1].Duration))
+	})
+
+	It("discards everything when nil", func() {
+		var recorder *Recorder
+
+		recorder.Record("some-operation", time.Second, 0, nil)
+		recorder.StartPhase("some-phase")()
+	})
+})
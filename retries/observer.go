@@ -0,0 +1,42 @@
+// Package retries lets a caller observe the retries the cf, perm and uaa packages make on its behalf
+package retries
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff"
+)
+
+// Observer is called before each retry with the time waited before it
+type Observer func(step time.Duration)
+
+type observerKey struct{}
+
+// WithObserver returns a context whose retries are reported to o, as well as to the observers of ctx
+func WithObserver(ctx context.Context, o Observer) context.Context {
+	if parent, ok := ctx.Value(observerKey{}).(Observer); ok {
+		child := o
+		o = func(step time.Duration) {
+			child(step)
+			parent(step)
+		}
+	}
+
+	return context.WithValue(ctx, observerKey{}, o)
+}
+
+// Observe reports a retry after waiting step to the observers of ctx
+func Observe(ctx context.Context, step time.Duration) {
+	if o, ok := ctx.Value(observerKey{}).(Observer); ok {
+		o(step)
+	}
+}
+
+// Notify returns a backoff.Notify which reports each retry to the observers of ctx before calling notify
+func Notify(ctx context.Context, notify backoff.Notify) backoff.Notify {
+	return func(err error, step time.Duration) {
+		Observe(ctx, step)
+		notify(err, step)
+	}
+}
@@ -0,0 +1,39 @@
+package retries_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/pivotal-cf/perm-test/retries"
+)
+
+var _ = Describe("Observer", func() {
+	It("reports each retry to every observer of the context before notifying", func() {
+		var outer, inner, notified []time.Duration
+		ctx := WithObserver(context.Background(), func(step time.Duration) {
+			outer = append(outer, step)
+		})
+		ctx = WithObserver(ctx, func(step time.Duration) {
+			inner = append(inner, step)
+		})
+
+		notify := Notify(ctx, func(err error, step time.Duration) {
+			Expect(inner).To(ContainElement(step))
+			notified = append(notified, step)
+		})
+		notify(errors.New("boom"), time.Second)
+		notify(errors.New("boom"), 2*time.Second)
+
+		Expect(outer).To(Equal([]time.Duration{time.Second, 2 * time.Second}))
+		Expect(inner).To(Equal(outer))
+		Expect(notified).To(Equal(outer))
+	})
+
+	It("does nothing without an observer", func() {
+		Observe(context.Background(), time.Second)
+	})
+})
@@ -0,0 +1,154 @@
+package uaa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/cenkalti/backoff"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// StatusError is returned when UAA responds with an unexpected status code
+type StatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("uaa: unexpected status code (%d): %s", e.StatusCode, strings.TrimSpace(e.Body))
+}
+
+// Client is a client for the UAA SCIM users API
+//
+// Requests are authenticated with a token fetched using the client credentials grant,
+// so the client must have the scim.read and scim.write authorities.
+// Requests rejected with a 4xx status code other than 409 or 429 are not retried.
+type Client struct {
+	// BackOff returns the backoff each request is retried with, defaulting to backoff.NewExponentialBackOff
+	BackOff func() backoff.BackOff
+
+	url        string
+	httpClient *http.Client
+}
+
+// NewClient returns a client for the UAA at uaaURL whose requests, including
+// those for tokens, are made through httpClient
+func NewClient(uaaURL string, clientID string, clientSecret string, httpClient *http.Client) *Client {
+	uaaURL = strings.TrimSuffix(uaaURL, "/")
+
+	config := &clientcredentials.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     uaaURL + "/oauth/token",
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, httpClient)
+
+	return &Client{
+		url:        uaaURL,
+		httpClient: config.Client(ctx),
+	}
+}
+
+type scimEmail struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary"`
+}
+
+type scimUser struct {
+	ID       string      `json:"id,omitempty"`
+	UserName string      `json:"userName"`
+	Password string      `json:"password,omitempty"`
+	Origin   string      `json:"origin,omitempty"`
+	Emails   []scimEmail `json:"emails,omitempty"`
+}
+
+type scimUsers struct {
+	Resources []scimUser `json:"resources"`
+}
+
+func (c *Client) do(ctx context.Context, method string, path string, body interface{}, expected int, result interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, c.url+path, r)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	contents, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != expected {
+		return &StatusError{StatusCode: resp.StatusCode, Body: string(contents)}
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(contents, result)
+}
+
+// IsAlreadyExists returns true if UAA reported that a user with the name already exists
+func IsAlreadyExists(err error) bool {
+	statusErr, ok := err.(*StatusError)
+	return ok && statusErr.StatusCode == http.StatusConflict
+}
+
+// permanent returns err as a *backoff.PermanentError if retrying the request cannot succeed,
+// i.e. UAA or its token endpoint rejected it with a 4xx status code other than
+// 409 Conflict, which means the user already exists, or 429 Too Many Requests
+func permanent(err error) error {
+	var code int
+	switch e := err.(type) {
+	case *StatusError:
+		code = e.StatusCode
+	case *url.Error:
+		if retrieveErr, ok := e.Err.(*oauth2.RetrieveError); ok && retrieveErr.Response != nil {
+			code = retrieveErr.Response.StatusCode
+		}
+	}
+
+	if code >= 400 && code < 500 && code != http.StatusConflict && code != http.StatusTooManyRequests {
+		return backoff.Permanent(err)
+	}
+	return err
+}
+
+func (c *Client) newBackOff(ctx context.Context) backoff.BackOff {
+	if c.BackOff == nil {
+		return backoff.WithContext(backoff.NewExponentialBackOff(), ctx)
+	}
+	return backoff.WithContext(c.BackOff(), ctx)
+}
+
+func usersFilter(userName string) string {
+	filter := fmt.Sprintf(`userName eq "%s"`, userName)
+	return "/Users?attributes=id,userName&filter=" + url.QueryEscape(filter)
+}
@@ -0,0 +1,131 @@
+package uaa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/lager"
+	"github.com/cenkalti/backoff"
+	"github.com/pivotal-cf/perm-test/retries"
+)
+
+var ErrUserNotFound = errors.New("uaa: user not found")
+
+// CreateUser creates a user in UAA which can log in with the password, and returns its ID
+// It uses the client's backoff strategy, returning early if it successfully creates
+// the user or a user with the name already exists, in which case the existing user's ID is returned
+func (c *Client) CreateUser(ctx context.Context, logger lager.Logger, userName string, password string) (string, error) {
+	logger.Debug("creating-uaa-user", lager.Data{
+		"username": userName,
+	})
+
+	request := scimUser{
+		UserName: userName,
+		Password: password,
+		Origin:   "uaa",
+		Emails:   []scimEmail{{Value: userName + "@example.com", Primary: true}},
+	}
+
+	var id string
+	operation := func() error {
+		var user scimUser
+		err := c.do(ctx, "POST", "/Users", request, http.StatusCreated, &user)
+		if IsAlreadyExists(err) {
+			logger.Debug("uaa-user-already-exists")
+			id, err = c.userID(ctx, userName)
+			return permanent(err)
+		}
+		if err != nil {
+			return permanent(err)
+		}
+
+		id = user.ID
+		return nil
+	}
+
+	err := backoff.RetryNotify(operation, c.newBackOff(ctx), retries.Notify(ctx, func(err error, step time.Duration) {
+		logger.Error("failed-to-create-uaa-user", err, lager.Data{
+			"backoff.step": step.String(),
+		})
+	}))
+	if err != nil {
+		logger.Error("finally-failed-to-create-uaa-user", err)
+		return "", err
+	}
+
+	return id, nil
+}
+
+// UserID returns the ID of the user with the name, or ErrUserNotFound if there is no such user
+// It uses the client's backoff strategy, but does not retry if the user does not exist
+func (c *Client) UserID(ctx context.Context, logger lager.Logger, userName string) (string, error) {
+	var id string
+	operation := func() error {
+		var err error
+		id, err = c.userID(ctx, userName)
+		if err == ErrUserNotFound {
+			return backoff.Permanent(err)
+		}
+		return permanent(err)
+	}
+
+	err := backoff.RetryNotify(operation, c.newBackOff(ctx), retries.Notify(ctx, func(err error, step time.Duration) {
+		logger.Error("failed-to-find-uaa-user", err, lager.Data{
+			"backoff.step": step.String(),
+		})
+	}))
+	if err != nil {
+		logger.Error("finally-failed-to-find-uaa-user", err, lager.Data{
+			"username": userName,
+		})
+		return "", err
+	}
+
+	return id, nil
+}
+
+// DeleteUser deletes the user from UAA
+// It uses the client's backoff strategy, and a user which has already been deleted is not an error
+func (c *Client) DeleteUser(ctx context.Context, logger lager.Logger, id string) error {
+	logger.Debug("deleting-uaa-user", lager.Data{
+		"id": id,
+	})
+
+	operation := func() error {
+		err := c.do(ctx, "DELETE", fmt.Sprintf("/Users/%s", id), nil, http.StatusOK, nil)
+		if statusErr, ok := err.(*StatusError); ok && statusErr.StatusCode == http.StatusNotFound {
+			logger.Debug("uaa-user-already-deleted")
+			return nil
+		}
+		return permanent(err)
+	}
+
+	err := backoff.RetryNotify(operation, c.newBackOff(ctx), retries.Notify(ctx, func(err error, step time.Duration) {
+		logger.Error("failed-to-delete-uaa-user", err, lager.Data{
+			"backoff.step": step.String(),
+		})
+	}))
+	if err != nil {
+		logger.Error("finally-failed-to-delete-uaa-user", err)
+	}
+	return err
+}
+
+func (c *Client) userID(ctx context.Context, userName string) (string, error) {
+	var users scimUsers
+	err := c.do(ctx, "GET", usersFilter(userName), nil, http.StatusOK, &users)
+	if err != nil {
+		return "", err
+	}
+
+	for _, u := range users.Resources {
+		if u.UserName == userName {
+			return u.ID, nil
+		}
+	}
+
+	return "", ErrUserNotFound
+}
@@ -0,0 +1,153 @@
+package uaa_test
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/lager/lagertest"
+	"github.com/cenkalti/backoff"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/pivotal-cf/perm-test/uaa"
+)
+
+var _ = Describe("Users", func() {
+	var (
+		server *ghttp.Server
+		client *Client
+		logger *lagertest.TestLogger
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		server.RouteToHandler("POST", "/oauth/token", ghttp.CombineHandlers(
+			ghttp.VerifyBasicAuth("some-client", "some-secret"),
+			ghttp.VerifyFormKV("grant_type", "client_credentials"),
+			ghttp.RespondWithJSONEncoded(http.StatusOK, map[string]interface{}{
+				"access_token": "some-token",
+				"token_type":   "bearer",
+				"expires_in":   3600,
+			}),
+		))
+
+		client = NewClient(server.URL(), "some-client", "some-secret", http.DefaultClient)
+		logger = lagertest.NewTestLogger("uaa")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("CreateUser", func() {
+		It("creates the user with the password and returns its ID", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("POST", "/Users"),
+				ghttp.VerifyHeaderKV("Authorization", "Bearer some-token"),
+				ghttp.VerifyJSON(`{
+					"userName": "perm-external-user-0",
+					"password": "some-password",
+					"origin": "uaa",
+					"emails": [{"value": "perm-external-user-0@example.com", "primary": true}]
+				}`),
+				ghttp.RespondWithJSONEncoded(http.StatusCreated, map[string]interface{}{
+					"id":       "some-user-id",
+					"userName": "perm-external-user-0",
+				}),
+			))
+
+			id, err := client.CreateUser(context.Background(), logger, "perm-external-user-0", "some-password")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(id).To(Equal("some-user-id"))
+		})
+
+		It("returns the ID of the existing user if the name is taken", func() {
+			server.AppendHandlers(
+				ghttp.RespondWith(http.StatusConflict, `{"error":"scim_resource_already_exists"}`),
+				ghttp.CombineHandlers(
+					ghttp.VerifyRequest("GET", "/Users", `attributes=id,userName&filter=userName+eq+%22perm-external-user-0%22`),
+					ghttp.RespondWithJSONEncoded(http.StatusOK, map[string]interface{}{
+						"resources": []map[string]string{
+							{"id": "existing-user-id", "userName": "perm-external-user-0"},
+						},
+					}),
+				),
+			)
+
+			id, err := client.CreateUser(context.Background(), logger, "perm-external-user-0", "some-password")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(id).To(Equal("existing-user-id"))
+		})
+	})
+
+	Describe("UserID", func() {
+		It("returns ErrUserNotFound without retrying if there is no such user", func() {
+			server.AppendHandlers(ghttp.RespondWithJSONEncoded(http.StatusOK, map[string]interface{}{
+				"resources": []map[string]string{},
+			}))
+
+			_, err := client.UserID(context.Background(), logger, "perm-external-user-0")
+			Expect(err).To(Equal(ErrUserNotFound))
+			Expect(server.ReceivedRequests()).To(HaveLen(2))
+		})
+
+		It("cancels a request in flight when the context is done", func() {
+			server.AppendHandlers(func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			_, err := client.UserID(ctx, logger, "perm-external-user-0")
+			Expect(err).To(HaveOccurred())
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		})
+	})
+
+	It("does not retry requests which UAA rejects", func() {
+		server.AppendHandlers(ghttp.RespondWith(http.StatusBadRequest, `{"error": "invalid_password"}`))
+
+		_, err := client.CreateUser(context.Background(), logger, "perm-external-user-0", "")
+		Expect(err).To(Equal(&StatusError{StatusCode: http.StatusBadRequest, Body: `{"error": "invalid_password"}`}))
+		Expect(server.ReceivedRequests()).To(HaveLen(2))
+	})
+
+	It("does not retry if the client's credentials are rejected", func() {
+		server.RouteToHandler("POST", "/oauth/token", ghttp.RespondWith(http.StatusUnauthorized, `{"error": "unauthorized"}`))
+
+		err := client.DeleteUser(context.Background(), logger, "some-user-id")
+		Expect(err).To(HaveOccurred())
+		Expect(server.ReceivedRequests()).To(HaveLen(1))
+	})
+
+	It("retries with the client's backoff", func() {
+		client.BackOff = func() backoff.BackOff {
+			return &backoff.StopBackOff{}
+		}
+		server.AppendHandlers(ghttp.RespondWith(http.StatusServiceUnavailable, `{}`))
+
+		Expect(client.DeleteUser(context.Background(), logger, "some-user-id")).NotTo(Succeed())
+		Expect(server.ReceivedRequests()).To(HaveLen(2))
+	})
+
+	Describe("DeleteUser", func() {
+		It("deletes the user", func() {
+			server.AppendHandlers(ghttp.CombineHandlers(
+				ghttp.VerifyRequest("DELETE", "/Users/some-user-id"),
+				ghttp.RespondWith(http.StatusOK, `{}`),
+			))
+
+			Expect(client.DeleteUser(context.Background(), logger, "some-user-id")).To(Succeed())
+		})
+
+		It("does not fail if the user has already been deleted", func() {
+			server.AppendHandlers(ghttp.RespondWith(http.StatusNotFound, `{}`))
+
+			Expect(client.DeleteUser(context.Background(), logger, "some-user-id")).To(Succeed())
+		})
+	})
+})